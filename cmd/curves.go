@@ -0,0 +1,21 @@
+package main
+
+import (
+	"crypto/elliptic"
+
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+)
+
+func curveByName(name string) (elliptic.Curve, bool) {
+	curve, err := crypto.CurveByName(name)
+	return curve, err == nil
+}
+
+func hashByName(name string) (crypto.HashFunction, bool) {
+	for _, hash := range crypto.HashFunctions {
+		if hash.String() == name {
+			return hash, true
+		}
+	}
+	return 0, false
+}
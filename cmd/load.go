@@ -0,0 +1,194 @@
+package main
+
+import (
+	"crypto/elliptic"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+	"github.com/GrzegorzManiak/GOWL/pkg/owl"
+)
+
+// loadUser is a registered user, the client side and the records a server
+// would keep in its database.
+type loadUser struct {
+	client             *owl.Client
+	registration       *owl.RegistrationRequestPayload
+	serverRegistration *owl.RegistrationResponse
+}
+
+// loadResult is the latency of one login, as a whole and on the server.
+type loadResult struct {
+	login  time.Duration
+	server time.Duration
+	err    error
+}
+
+func runLoad(args []string) {
+	flags := flag.NewFlagSet("load", flag.ExitOnError)
+	curveName := flags.String("curve", "P-256", "curve: P-256, P-384, P-521, secp256k1 or edwards25519")
+	concurrency := flags.Int("c", runtime.GOMAXPROCS(0), "number of concurrent logins")
+	logins := flags.Int("n", 1000, "number of logins, ignored if -d is set")
+	duration := flags.Duration("d", 0, "run logins for this long")
+	userCount := flags.Int("users", 100, "number of registered users")
+	hashName := flags.String("hash", "SHA-256", "hash: SHA-256, SHA-384, SHA-512, SHA3-256, SHA3-384 or SHA3-512")
+	version := flags.Uint("version", 0, "protocol version of the registrations")
+	codecName := flags.String("codec", "compressed", "point encoding: compressed, uncompressed or raw")
+	profiles := addProfileFlags(flags)
+	_ = flags.Parse(args)
+
+	curve, ok := curveByName(*curveName)
+	hash, hashOK := hashByName(*hashName)
+	codec, codecErr := crypto.PointCodecByName(*codecName)
+	if !ok || !hashOK || codecErr != nil || *concurrency < 1 || *userCount < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	opts := []owl.Option{owl.WithHash(hash), owl.WithVersion(owl.Version(*version)), owl.WithPointCodec(codec)}
+
+	const serverName = "server"
+	users := make([]loadUser, *userCount)
+	for i := range users {
+		client, err := owl.ClientInit(fmt.Sprintf("user-%d", i), "password", serverName, curve, opts...)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		registration := client.Register().Payload
+		server, err := owl.ServerInit(serverName, curve, registration, opts...)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		users[i] = loadUser{client, registration, server.RegisterUser()}
+	}
+
+	stop := profiles.start()
+	defer stop()
+
+	// -- Every login takes the next number, until -n or -d is reached
+	var next atomic.Int64
+	deadline := time.Time{}
+	if *duration > 0 {
+		deadline = time.Now().Add(*duration)
+	}
+
+	results := make([][]loadResult, *concurrency)
+	var wait sync.WaitGroup
+	started := time.Now()
+
+	for worker := range results {
+		wait.Add(1)
+		go func(worker int) {
+			defer wait.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if deadline.IsZero() && i >= *logins || !deadline.IsZero() && time.Now().After(deadline) {
+					return
+				}
+				results[worker] = append(results[worker], loadLogin(serverName, curve, users[i%len(users)], opts))
+			}
+		}(worker)
+	}
+
+	wait.Wait()
+	elapsed := time.Since(started)
+	stop()
+
+	var all []loadResult
+	for _, workerResults := range results {
+		all = append(all, workerResults...)
+	}
+	reportLoad(curve, *concurrency, elapsed, all)
+}
+
+// loadLogin runs one complete login. The server is created from the stored
+// records for every login, as a stateless service would.
+func loadLogin(serverName string, curve elliptic.Curve, user loadUser, opts []owl.Option) loadResult {
+	started := time.Now()
+	var serverTime time.Duration
+
+	serverStep := func(step func() error) error {
+		stepStarted := time.Now()
+		err := step()
+		serverTime += time.Since(stepStarted)
+		return err
+	}
+
+	var server *owl.Server
+	var serverInit *owl.ServerAuthInitResponse
+	var serverValidate *owl.ServerAuthValidateResponse
+
+	clientInit := user.client.AuthInit()
+	err := serverStep(func() (err error) {
+		server, err = owl.ServerInit(serverName, curve, user.registration, opts...)
+		if err != nil {
+			return err
+		}
+		serverInit, err = server.AuthInit(user.serverRegistration, clientInit.Payload)
+		return err
+	})
+	if err != nil {
+		return loadResult{err: err}
+	}
+
+	clientValidate, err := user.client.AuthValidate(clientInit, serverInit.Payload)
+	if err != nil {
+		return loadResult{err: err}
+	}
+
+	err = serverStep(func() (err error) {
+		serverValidate, err = server.AuthValidate(clientInit.Payload, clientValidate.Payload, serverInit)
+		return err
+	})
+	if err != nil {
+		return loadResult{err: err}
+	}
+
+	err = user.client.VerifyResponse(clientInit, clientValidate, serverInit.Payload, serverValidate.Payload)
+	return loadResult{login: time.Since(started), server: serverTime, err: err}
+}
+
+func reportLoad(curve elliptic.Curve, concurrency int, elapsed time.Duration, results []loadResult) {
+	var logins, servers []time.Duration
+	failures := 0
+	for _, result := range results {
+		if result.err != nil {
+			failures++
+			continue
+		}
+		logins = append(logins, result.login)
+		servers = append(servers, result.server)
+	}
+
+	fmt.Printf("curve        %s\n", curve.Params().Name)
+	fmt.Printf("concurrency  %d (GOMAXPROCS %d)\n", concurrency, runtime.GOMAXPROCS(0))
+	fmt.Printf("logins       %d ok, %d failed in %s\n", len(logins), failures, elapsed.Round(time.Millisecond))
+	fmt.Printf("throughput   %.1f logins/s\n", float64(len(logins))/elapsed.Seconds())
+	fmt.Println()
+	fmt.Printf("%-8s %10s %10s %10s %10s %10s\n", "latency", "p50", "p90", "p99", "p99.9", "max")
+	printPercentiles("login", logins)
+	printPercentiles("server", servers)
+}
+
+func printPercentiles(name string, durations []time.Duration) {
+	if len(durations) == 0 {
+		return
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	percentile := func(p float64) time.Duration {
+		return durations[int(p*float64(len(durations)-1))].Round(time.Microsecond)
+	}
+
+	fmt.Printf("%-8s %10s %10s %10s %10s %10s\n", name,
+		percentile(0.5), percentile(0.9), percentile(0.99), percentile(0.999), durations[len(durations)-1].Round(time.Microsecond))
+}
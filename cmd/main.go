@@ -1,74 +1,33 @@
 package main
 
 import (
-	"crypto/elliptic"
 	"fmt"
-	"github.com/GrzegorzManiak/GOWL/pkg/owl"
+	"os"
 )
 
-func main() {
-	curve := elliptic.P256()
-	user := "Alice"
-	pass := "deadbeef"
-	serverName := "Server"
-
-	// -- Register
-	client, err := owl.ClientInit(user, pass, serverName, curve)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
-	clientRegistration := client.Register()
-
-	server, err := owl.ServerInit(serverName, curve, clientRegistration.Payload)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
-	serverRegistration := server.RegisterUser()
-
-	// -- Auth Init
+const usage = `usage: cmd [command] [flags]
 
-	// >>>>
-	clientInit := client.AuthInit()
+commands:
+  demo    register a user and run one login (default)
+  load    run concurrent synthetic logins, see load -h
+`
 
-	// <<<<
-	serverInit, err := server.AuthInit(serverRegistration, clientInit.Payload)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
-	// -- Auth Validate
-	// >>>>
-	clientValidate, err := client.AuthValidate(clientInit, serverInit.Payload)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
-	// <<<<
-	serverValidate, err := server.AuthValidate(clientInit.Payload, clientValidate.Payload, serverInit)
-	if err != nil {
-		fmt.Println(err)
-		return
+func main() {
+	command := "demo"
+	args := os.Args[1:]
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
 	}
 
-	println("Client Session Key:", clientValidate.ClientSessionKey.String())
-	println("Server Session Key:", serverValidate.ServerSessionKey.String())
-
-	// -- Verify Response (Optional)
-	err = client.VerifyResponse(
-		clientInit,
-		clientValidate,
-		serverInit.Payload,
-		serverValidate.Payload,
-	)
-
-	if err != nil {
-		fmt.Println(err)
-		return
+	switch command {
+	case "demo":
+		runDemo()
+	case "load":
+		runLoad(args)
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
 	}
 }
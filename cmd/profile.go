@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+type profileFlags struct {
+	cpu *string
+	mem *string
+}
+
+func addProfileFlags(flags *flag.FlagSet) profileFlags {
+	return profileFlags{
+		cpu: flags.String("cpuprofile", "", "write a CPU profile to this file"),
+		mem: flags.String("memprofile", "", "write a heap profile to this file when done"),
+	}
+}
+
+// start starts the CPU profile, the returned function stops it and writes
+// the heap profile. It may be called more than once.
+func (profiles profileFlags) start() func() {
+	var cpuFile *os.File
+	if *profiles.cpu != "" {
+		file, err := os.Create(*profiles.cpu)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		cpuFile = file
+	}
+
+	stopped := false
+	return func() {
+		if stopped {
+			return
+		}
+		stopped = true
+
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			cpuFile.Close()
+		}
+
+		if *profiles.mem != "" {
+			file, err := os.Create(*profiles.mem)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return
+			}
+			defer file.Close()
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(file); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+)
+
+// batchWeightBits is the size of the random weights of VerifyZKPBatch, a
+// batch containing an invalid proof is accepted with probability 2^-128.
+const batchWeightBits = 128
+
+// ZKPStatement is a Schnorr proof together with what it is verified against,
+// the arguments of VerifyZKPPoint.
+type ZKPStatement struct {
+	Generator *Point
+	X         *Point
+	ZKP       SchnorrZKP
+	Prover    string
+}
+
+// multiScalarMultiplier is implemented by the curves with a multi-scalar
+// multiplication, secp256k1. The curves of crypto/elliptic only expose
+// whole scalar multiplications, a combination of them costs as much as
+// verifying each proof.
+type multiScalarMultiplier interface {
+	// multiScalarMult returns Σ k_i·P_i in variable time, each k_i is
+	// ScalarSize bytes, big-endian.
+	multiScalarMult(points []element, scalars [][]byte) element
+}
+
+// VerifyZKPBatch verifies several Schnorr proofs at once. Each proof claims
+// V = G·r + X·h, on a curve with a multi-scalar multiplication the batch
+// checks the random linear combination
+//
+//	Σ z_i·V_i - Σ (z_i·r_i)·G_i - Σ (z_i·h_i)·X_i = 0
+//
+// with z_0 = 1 and 128-bit random z_i, as one multi-scalar multiplication
+// in which proofs sharing a generator share a term. On the other curves,
+// and on curves with a cofactor, each proof is verified on its own.
+//
+// It returns (-1, true) if every proof is valid. Otherwise the index of the
+// first invalid proof is returned, so callers can report which proof
+// failed: after a failed combination the proofs are verified one by one,
+// the last only if all the others are valid.
+func VerifyZKPBatch(curve elliptic.Curve, statements []ZKPStatement) (int, bool) {
+	return LegacySuite(curve).VerifyZKPBatch(statements)
+}
+
+// VerifyZKPBatch is VerifyZKPBatch for the proofs of suite.
+func (suite *Suite) VerifyZKPBatch(statements []ZKPStatement) (int, bool) {
+	if !suite.CombinesZKPs() || len(statements) < 2 {
+		return suite.verifyZKPEach(statements)
+	}
+
+	if suite.VerifyZKPCombined(statements) {
+		return -1, true
+	}
+
+	// -- At least one proof is invalid, if it is not one of the others it
+	// is the last
+	last := len(statements) - 1
+	if i, valid := suite.verifyZKPEach(statements[:last]); !valid {
+		return i, false
+	}
+	return last, false
+}
+
+// CombinesZKPs reports whether VerifyZKPBatch checks the proofs of suite as
+// one combination: the curve has a multi-scalar multiplication and no
+// cofactor. A small order component of V cancels out of the combination
+// when its weight is a multiple of its order, with a cofactor every proof
+// is checked alone.
+func (suite *Suite) CombinesZKPs() bool {
+	_, ok := suite.Curve.(multiScalarMultiplier)
+	return ok && CalculateCofactor(suite.Curve).Cmp(big.NewInt(1)) == 0
+}
+
+// VerifyZKPCombined is the combined check of VerifyZKPBatch, it reports
+// whether every proof is valid without finding the invalid one. Where
+// CombinesZKPs is false it verifies each proof.
+func (suite *Suite) VerifyZKPCombined(statements []ZKPStatement) bool {
+	msm, ok := suite.Curve.(multiScalarMultiplier)
+	if !ok || !suite.CombinesZKPs() {
+		_, valid := suite.verifyZKPEach(statements)
+		return valid
+	}
+	return suite.verifyZKPCombined(msm, statements)
+}
+
+func (suite *Suite) verifyZKPEach(statements []ZKPStatement) (int, bool) {
+	for i, statement := range statements {
+		if !suite.VerifyZKP(statement.Generator, statement.X, statement.ZKP, statement.Prover) {
+			return i, false
+		}
+	}
+	return -1, true
+}
+
+func (suite *Suite) verifyZKPCombined(msm multiScalarMultiplier, statements []ZKPStatement) bool {
+	curve := suite.Curve
+	n := curve.Params().N
+	terms := make([]scalarTerm, 0, 3*len(statements))
+
+	for i, statement := range statements {
+		if statement.Generator == nil || !zkpPublicKeyValid(statement.X, statement.ZKP) {
+			return false
+		}
+
+		V, err := suite.Codec.Decode(curve, statement.ZKP.V)
+		if err != nil {
+			return false
+		}
+
+		h, err := suite.zkpChallenge(statement.Generator, statement.ZKP.V, suite.Codec.Encode(statement.X), statement.Prover)
+		if err != nil {
+			return false
+		}
+
+		z := big.NewInt(1)
+		if i > 0 {
+			z = randomWeight()
+		}
+
+		// -- -k is N - k
+		zr := new(big.Int).Mul(z, statement.ZKP.R)
+		zh := new(big.Int).Mul(z, h)
+		terms = append(terms,
+			scalarTerm{V, z},
+			scalarTerm{statement.Generator, zr.Sub(n, zr.Mod(zr, n))},
+			scalarTerm{statement.X, zh.Sub(n, zh.Mod(zh, n))},
+		)
+	}
+
+	return multiScalarMult(msm, curve, terms).IsIdentity()
+}
+
+func randomWeight() *big.Int {
+	z, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), batchWeightBits))
+	if err != nil {
+		panic(err)
+	}
+	// -- A zero weight would drop its proof from the check
+	return z.Add(z, big.NewInt(1))
+}
+
+// scalarTerm is k·P.
+type scalarTerm struct {
+	point  *Point
+	scalar *big.Int
+}
+
+// multiScalarMult computes Σ k_i·P_i with the multi-scalar multiplication
+// of the curve. Terms with the same point are merged first.
+func multiScalarMult(msm multiScalarMultiplier, curve elliptic.Curve, terms []scalarTerm) *Point {
+	n := curve.Params().N
+
+	merged := make([]scalarTerm, 0, len(terms))
+	index := make(map[string]int, len(terms))
+	for _, term := range terms {
+		key := string(term.point.Encode())
+		if i, seen := index[key]; seen {
+			merged[i].scalar.Add(merged[i].scalar, term.scalar)
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, scalarTerm{term.point, new(big.Int).Set(term.scalar)})
+	}
+
+	points := make([]element, len(merged))
+	scalars := make([][]byte, len(merged))
+	for i, term := range merged {
+		points[i] = term.point.element
+		scalars[i] = term.scalar.Mod(term.scalar, n).FillBytes(make([]byte, ScalarSize(curve)))
+	}
+	return &Point{curve: curve, element: msm.multiScalarMult(points, scalars)}
+}
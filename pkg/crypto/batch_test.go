@@ -0,0 +1,101 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+)
+
+// zkpStatements returns valid proofs, overG of them over the curves base
+// point and other over a random generator.
+func zkpStatements(curve elliptic.Curve, overG int, other int) []ZKPStatement {
+	n := curve.Params().N
+	G := BasePoint(curve)
+	otherG := MultiplyBase(curve, GenerateKey(curve))
+
+	statements := make([]ZKPStatement, 0, overG+other)
+	for i := 0; i < overG+other; i++ {
+		generator := G
+		if i >= overG {
+			generator = otherG
+		}
+
+		x := GenerateKey(curve)
+		X := generator.Multiply(x)
+		zkp := GenerateZKPPoint(generator, n, x, X.Encode(), "prover")
+		statements = append(statements, ZKPStatement{Generator: generator, X: X, ZKP: *zkp, Prover: "prover"})
+	}
+	return statements
+}
+
+func TestVerifyZKPBatch(t *testing.T) {
+	for _, id := range Curves {
+		curve, _ := id.Curve()
+		t.Run(id.String(), func(t *testing.T) {
+			statements := zkpStatements(curve, 2, 1)
+			if i, ok := VerifyZKPBatch(curve, statements); !ok {
+				t.Fatalf("valid batch rejected at %d", i)
+			}
+
+			for bad := range statements {
+				tampered := append([]ZKPStatement(nil), statements...)
+				tampered[bad].ZKP.R = new(big.Int).Add(tampered[bad].ZKP.R, big.NewInt(1))
+				if i, ok := VerifyZKPBatch(curve, tampered); ok || i != bad {
+					t.Errorf("proof %d tampered: got (%d, %v)", bad, i, ok)
+				}
+			}
+
+			// -- Every proof valid on its own, but bound to another key
+			swapped := append([]ZKPStatement(nil), statements...)
+			swapped[0].X, swapped[1].X = swapped[1].X, swapped[0].X
+			if i, ok := VerifyZKPBatch(curve, swapped); ok || i != 0 {
+				t.Errorf("swapped keys: got (%d, %v)", i, ok)
+			}
+		})
+	}
+}
+
+func BenchmarkGenerateZKP(b *testing.B) {
+	for _, id := range Curves {
+		curve, _ := id.Curve()
+		b.Run(id.String(), func(b *testing.B) {
+			G := BasePoint(curve)
+			x := GenerateKey(curve)
+			X := G.Multiply(x).Encode()
+			for i := 0; i < b.N; i++ {
+				GenerateZKPPoint(G, curve.Params().N, x, X, "prover")
+			}
+		})
+	}
+}
+
+// BenchmarkVerifyZKPs compares verifying the proofs of one login step one
+// by one with VerifyZKPBatch: 2 proofs over G (server AuthInit) and 2 over
+// G plus one over another generator (client AuthValidate).
+func BenchmarkVerifyZKPs(b *testing.B) {
+	for _, id := range Curves {
+		curve, _ := id.Curve()
+		for _, step := range []struct {
+			name         string
+			overG, other int
+		}{{"server-2", 2, 0}, {"client-3", 2, 1}} {
+			statements := zkpStatements(curve, step.overG, step.other)
+			b.Run(id.String()+"/Each/"+step.name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					for _, statement := range statements {
+						if !VerifyZKPPoint(statement.Generator, statement.X, statement.ZKP, statement.Prover) {
+							b.Fatal("valid proof rejected")
+						}
+					}
+				}
+			})
+			b.Run(id.String()+"/Batch/"+step.name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, ok := VerifyZKPBatch(curve, statements); !ok {
+						b.Fatal("valid batch rejected")
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+)
+
+var ErrUnsupportedCodec = errors.New("unsupported point encoding")
+
+// PointCodec is how points are encoded on the wire and in transcripts. The
+// zero value is the compressed encoding every implementation understands.
+type PointCodec uint8
+
+const (
+	// CodecCompressed is the compressed SEC 1 encoding, 0x02 or 0x03 || X,
+	// or the RFC 8032 encoding on edwards25519.
+	CodecCompressed PointCodec = iota
+
+	// CodecUncompressed is the uncompressed SEC 1 encoding, 0x04 || X || Y.
+	CodecUncompressed
+
+	// CodecRaw is X || Y without a prefix, each as wide as the field.
+	CodecRaw
+)
+
+// PointCodecs lists every supported PointCodec.
+var PointCodecs = []PointCodec{CodecCompressed, CodecUncompressed, CodecRaw}
+
+func (codec PointCodec) Available() bool {
+	return codec <= CodecRaw
+}
+
+func (codec PointCodec) String() string {
+	switch codec {
+	case CodecCompressed:
+		return "compressed"
+	case CodecUncompressed:
+		return "uncompressed"
+	case CodecRaw:
+		return "raw"
+	}
+	return "unknown"
+}
+
+// PointCodecByName returns the codec called name, e.g. "uncompressed".
+func PointCodecByName(name string) (PointCodec, error) {
+	for _, codec := range PointCodecs {
+		if codec.String() == name {
+			return codec, nil
+		}
+	}
+	return 0, ErrUnsupportedCodec
+}
+
+// Size is the length in bytes of an encoded point of curve.
+func (codec PointCodec) Size(curve elliptic.Curve) int {
+	switch codec {
+	case CodecUncompressed:
+		return 1 + 2*fieldSize(curve)
+	case CodecRaw:
+		return 2 * fieldSize(curve)
+	}
+	return compressedSize(curve)
+}
+
+// Encode returns the encoding of p.
+func (codec PointCodec) Encode(p *Point) []byte {
+	switch codec {
+	case CodecUncompressed:
+		x, y := p.coordinates()
+		return elliptic.Marshal(p.curve, x, y)
+	case CodecRaw:
+		x, y := p.coordinates()
+		return elliptic.Marshal(p.curve, x, y)[1:]
+	}
+	return p.Encode()
+}
+
+// Decode decodes a point of curve. The compressed encoding goes through
+// DecodePoint and its cache, the others carry both coordinates and only
+// need the check that they are on the curve.
+func (codec PointCodec) Decode(curve elliptic.Curve, X []byte) (*Point, error) {
+	var x, y *big.Int
+	switch codec {
+	case CodecCompressed:
+		return DecodePoint(curve, X)
+	case CodecUncompressed:
+		x, y = elliptic.Unmarshal(curve, X)
+	case CodecRaw:
+		if len(X) != codec.Size(curve) {
+			return nil, ErrInvalidPoint
+		}
+		x, y = elliptic.Unmarshal(curve, append([]byte{4}, X...))
+	default:
+		return nil, ErrUnsupportedCodec
+	}
+	if x == nil || y == nil {
+		return nil, ErrInvalidPoint
+	}
+	return newPoint(curve, x, y, nil), nil
+}
+
+// fieldSize is the length in bytes of a coordinate of curve.
+func fieldSize(curve elliptic.Curve) int {
+	return (curve.Params().BitSize + 7) / 8
+}
@@ -0,0 +1,59 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+)
+
+// p256Generator is the base point of P-256 (FIPS 186-4, D.1.2.3) in every
+// encoding.
+var p256Generator = map[PointCodec]string{
+	CodecCompressed:   "036b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296",
+	CodecUncompressed: "046b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c2964fe342e2fe1a7f9b8ee7eb4a7c0f9e162bce33576b315ececbb6406837bf51f5",
+	CodecRaw:          "6b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c2964fe342e2fe1a7f9b8ee7eb4a7c0f9e162bce33576b315ececbb6406837bf51f5",
+}
+
+func TestP256GeneratorEncodings(t *testing.T) {
+	for _, codec := range PointCodecs {
+		checkHex(t, "P-256 generator "+codec.String(), codec.Encode(BasePoint(elliptic.P256())), p256Generator[codec])
+	}
+}
+
+func TestPointCodecs(t *testing.T) {
+	for _, id := range Curves {
+		curve, _ := id.Curve()
+		X := MultiplyBase(curve, big.NewInt(0x0123456789))
+		for _, codec := range PointCodecs {
+			encoded := codec.Encode(X)
+			if len(encoded) != codec.Size(curve) {
+				t.Errorf("%s %s: %d bytes, Size %d", id, codec, len(encoded), codec.Size(curve))
+			}
+			decoded, err := codec.Decode(curve, encoded)
+			if err != nil || !decoded.Equal(X) {
+				t.Errorf("%s %s does not round trip: %v", id, codec, err)
+			}
+
+			// -- A point in any other encoding has the wrong length
+			for _, other := range PointCodecs {
+				if _, err := other.Decode(curve, encoded); other != codec && err == nil {
+					t.Errorf("%s %s decodes a %s point", id, other, codec)
+				}
+			}
+			if _, err := codec.Decode(curve, encoded[:len(encoded)-1]); err == nil {
+				t.Errorf("%s %s decodes a truncated point", id, codec)
+			}
+		}
+
+		// -- Flipping a bit of Y takes the point off the curve
+		raw := CodecRaw.Encode(X)
+		raw[len(raw)-1] ^= 1
+		if _, err := CodecRaw.Decode(curve, raw); err == nil {
+			t.Errorf("%s raw point off the curve decoded", id)
+		}
+	}
+
+	if _, err := PointCodec(3).Decode(elliptic.P256(), mustHex(t, p256Generator[CodecCompressed])); err != ErrUnsupportedCodec {
+		t.Errorf("unknown codec: got %v, want ErrUnsupportedCodec", err)
+	}
+}
@@ -0,0 +1,99 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+)
+
+// toyCurve is y² = x³ - 3x + 25 over GF(100003). It has 100588 = 4·25147
+// points, G generates the subgroup of prime order 25147 and toyLowOrder has
+// order 4. Far too small to be secure, it exercises the cofactor handling
+// on the generic arithmetic of crypto/elliptic, edwards25519 is the only
+// supported curve with a cofactor and has its own.
+type toyCurve struct {
+	*elliptic.CurveParams
+}
+
+var toy = toyCurve{&elliptic.CurveParams{
+	Name:    "toy",
+	BitSize: 17,
+	P:       big.NewInt(100003),
+	N:       big.NewInt(25147),
+	B:       big.NewInt(25),
+	Gx:      big.NewInt(55716),
+	Gy:      big.NewInt(22106),
+}}
+
+var toyLowOrder = [2]int64{96361, 49410}
+
+func (toyCurve) Cofactor() *big.Int {
+	return big.NewInt(4)
+}
+
+func TestCofactor(t *testing.T) {
+	if CalculateCofactor(elliptic.P256()).Cmp(big.NewInt(1)) != 0 {
+		t.Error("P-256 does not have cofactor 1")
+	}
+	if CalculateCofactor(Edwards25519()).Cmp(big.NewInt(8)) != 0 {
+		t.Error("edwards25519 does not have cofactor 8")
+	}
+	if CalculateCofactor(toy).Cmp(big.NewInt(4)) != 0 {
+		t.Error("toy curve does not have cofactor 4")
+	}
+}
+
+// TestToyCurveRejectsSmallOrder checks that points of small order, and
+// points with a small order component, are rejected as public keys even
+// when the proof equation holds.
+func TestToyCurveRejectsSmallOrder(t *testing.T) {
+	L, err := DecodePoint(toy, elliptic.MarshalCompressed(toy, big.NewInt(toyLowOrder[0]), big.NewInt(toyLowOrder[1])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	G := BasePoint(toy)
+	x := big.NewInt(12345)
+	X := G.Multiply(x)
+	mixed := X.Add(L)
+
+	if !G.InPrimeOrderSubgroup() || G.HasSmallOrder() {
+		t.Error("G is not in the prime order subgroup")
+	}
+	if L.InPrimeOrderSubgroup() || !L.HasSmallOrder() || smallOrder(L) != 4 {
+		t.Error("the low order point is in the prime order subgroup")
+	}
+	if mixed.InPrimeOrderSubgroup() || mixed.HasSmallOrder() {
+		t.Error("X + L is in the prime order subgroup")
+	}
+	if !mixed.ClearCofactor().Equal(X.Multiply(big.NewInt(4))) {
+		t.Error("clearing the cofactor of X + L does not give 4·X")
+	}
+
+	valid := GenerateZKPPoint(G, toy.N, x, X.Encode(), "prover")
+	if !VerifyZKPPoint(G, X, *valid, "prover") {
+		t.Fatal("proof for X does not verify")
+	}
+
+	// -- A proof for X + L (or L) whose challenge is a multiple of 4 satisfies
+	// V = G·r + X·h, L·h being the identity
+	for _, forgery := range []struct {
+		name   string
+		public *Point
+		secret *big.Int
+	}{
+		{"X + L", mixed, x},
+		{"L", L, new(big.Int)},
+	} {
+		zkp := forgeZKP(t, forgery.public, forgery.secret, 4, "prover")
+		if VerifyZKPPoint(G, forgery.public, zkp, "prover") {
+			t.Errorf("proof for %s accepted", forgery.name)
+		}
+		statements := []ZKPStatement{
+			{Generator: G, X: X, ZKP: *valid, Prover: "prover"},
+			{Generator: G, X: forgery.public, ZKP: zkp, Prover: "prover"},
+		}
+		if i, ok := VerifyZKPBatch(toy, statements); ok || i != 1 {
+			t.Errorf("batch with %s: got (%d, %v)", forgery.name, i, ok)
+		}
+	}
+}
@@ -28,6 +28,9 @@ func Subtract(x *big.Int, y *big.Int) *big.Int {
 	return new(big.Int).Sub(x, y)
 }
 
+// B64Encode encodes a *big.Int, or a []byte read as one, in base64. Like
+// the TS client it drops leading zero bytes, so the length of the result
+// varies, use B64EncodeScalar and B64EncodeBytes for fixed-length fields.
 func B64Encode(data interface{}) string {
 	switch data.(type) {
 	case *big.Int:
@@ -41,6 +44,14 @@ func B64Encode(data interface{}) string {
 	}
 }
 
+// B64Decode decodes standard base64, use it rather than B64DecodeBytes on
+// untrusted input.
+func B64Decode(encoded string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(encoded)
+}
+
+// B64DecodeBytes is B64Decode for trusted input, it panics if encoded is
+// not valid base64.
 func B64DecodeBytes(encoded string) []byte {
 	decoded, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
@@ -52,3 +63,54 @@ func B64DecodeBytes(encoded string) []byte {
 func B64DecodeBigInt(encoded string) *big.Int {
 	return new(big.Int).SetBytes(B64DecodeBytes(encoded))
 }
+
+// B64EncodeScalar encodes k with EncodeScalar, as wide as the group order
+// of curve.
+func B64EncodeScalar(curve elliptic.Curve, k *big.Int) (string, error) {
+	encoded, err := EncodeScalar(curve, k)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encoded), nil
+}
+
+// B64DecodeScalar decodes a scalar of B64EncodeScalar, it fails with
+// ErrInvalidScalar unless the scalar is exactly as wide as the group order
+// and below it.
+func B64DecodeScalar(curve elliptic.Curve, encoded string) (*big.Int, error) {
+	data, err := B64Decode(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeScalar(curve, data)
+}
+
+// B64EncodeBytes encodes data in base64 as is, leading zero bytes included.
+// Use it for points and KC tags.
+func B64EncodeBytes(data []byte) string {
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// B64DecodeTag decodes a KC tag of B64EncodeBytes, it fails unless the tag
+// is exactly as long as a digest of function.
+func B64DecodeTag(function HashFunction, encoded string) ([]byte, error) {
+	tag, err := B64Decode(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(tag) != function.Size() {
+		return nil, ErrInvalidTag
+	}
+	return tag, nil
+}
+
+// LeftPad returns data left-padded with zeros to size bytes. Data that is
+// already size bytes or longer is returned unchanged.
+func LeftPad(data []byte, size int) []byte {
+	if len(data) >= size {
+		return data
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(data):], data)
+	return padded
+}
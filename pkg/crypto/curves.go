@@ -0,0 +1,77 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"errors"
+)
+
+var ErrUnsupportedCurve = errors.New("unsupported curve")
+
+// CurveID is the stable identifier of a vetted curve. The values are the
+// NamedCurve codes of RFC 4492 / RFC 8422, so they never change with the
+// order of this list. edwards25519 has no code of its own, it takes the one
+// of x25519, the same group in Montgomery form.
+type CurveID uint16
+
+const (
+	CurveSecp256k1    CurveID = 22
+	CurveP256         CurveID = 23
+	CurveP384         CurveID = 24
+	CurveP521         CurveID = 25
+	CurveEdwards25519 CurveID = 29
+)
+
+// Curves lists every supported CurveID.
+var Curves = []CurveID{CurveP256, CurveP384, CurveP521, CurveSecp256k1, CurveEdwards25519}
+
+// Curve returns the curve of id.
+func (id CurveID) Curve() (elliptic.Curve, error) {
+	switch id {
+	case CurveP256:
+		return elliptic.P256(), nil
+	case CurveP384:
+		return elliptic.P384(), nil
+	case CurveP521:
+		return elliptic.P521(), nil
+	case CurveSecp256k1:
+		return Secp256k1(), nil
+	case CurveEdwards25519:
+		return Edwards25519(), nil
+	}
+	return nil, ErrUnsupportedCurve
+}
+
+// String is the name of the curve, as in its Params.
+func (id CurveID) String() string {
+	curve, err := id.Curve()
+	if err != nil {
+		return "unknown"
+	}
+	return curve.Params().Name
+}
+
+// LookupCurve returns the identifier of curve, or ErrUnsupportedCurve if it
+// is not one of the vetted curves. Curves are matched by their parameters,
+// a copy of the parameters of a supported curve is not supported.
+func LookupCurve(curve elliptic.Curve) (CurveID, error) {
+	if curve == nil {
+		return 0, ErrUnsupportedCurve
+	}
+	for _, id := range Curves {
+		supported, _ := id.Curve()
+		if curve.Params() == supported.Params() {
+			return id, nil
+		}
+	}
+	return 0, ErrUnsupportedCurve
+}
+
+// CurveByName returns the supported curve called name, e.g. "P-256".
+func CurveByName(name string) (elliptic.Curve, error) {
+	for _, id := range Curves {
+		if id.String() == name {
+			return id.Curve()
+		}
+	}
+	return nil, ErrUnsupportedCurve
+}
@@ -7,65 +7,78 @@ import (
 )
 
 func GetG(curve elliptic.Curve) []byte {
-	curveParams := curve.Params()
-	return elliptic.MarshalCompressed(curve, curveParams.Gx, curveParams.Gy)
+	// -- Copied, the cached encoding is shared
+	return append([]byte(nil), generator(curve)...)
 }
 
 func MultiplyG(curve elliptic.Curve, x *big.Int) []byte {
-	curveParams := curve.Params()
-	tx, ty := curve.ScalarMult(curveParams.Gx, curveParams.Gy, x.Bytes())
-	return elliptic.MarshalCompressed(curve, tx, ty)
+	return MultiplyBase(curve, x).Encode()
 }
 
 func MultiplyPoint(curve elliptic.Curve, X *[]byte, x *big.Int) ([]byte, error) {
-	x1x, x1y := elliptic.UnmarshalCompressed(curve, *X)
-	if x1x == nil || x1y == nil {
-		return nil, errors.New("Point is invalid")
+	point, err := DecodePoint(curve, *X)
+	if err != nil {
+		return nil, err
 	}
-	tx, ty := curve.ScalarMult(x1x, x1y, x.Bytes())
-	return elliptic.MarshalCompressed(curve, tx, ty), nil
+	return point.Multiply(x).Encode(), nil
 }
 
 func AddPoints(curve elliptic.Curve, x1 []byte, x2 []byte) ([]byte, error) {
-	x1x, x1y := elliptic.UnmarshalCompressed(curve, x1)
-	x2x, x2y := elliptic.UnmarshalCompressed(curve, x2)
-	if x1x == nil || x1y == nil || x2x == nil || x2y == nil {
-		return nil, errors.New("one or more points are invalid")
+	p1, p2, err := decodePoints(curve, x1, x2)
+	if err != nil {
+		return nil, err
 	}
-	tx, ty := curve.Add(x1x, x1y, x2x, x2y)
-	return elliptic.MarshalCompressed(curve, tx, ty), nil
+	return p1.Add(p2).Encode(), nil
 }
 
 func SubtractPoints(curve elliptic.Curve, x1 []byte, x2 []byte) ([]byte, error) {
-	x1x, x1y := elliptic.UnmarshalCompressed(curve, x1)
-	x2x, x2y := elliptic.UnmarshalCompressed(curve, x2)
-	if x1x == nil || x1y == nil || x2x == nil || x2y == nil {
-		return nil, errors.New("one or more points are invalid")
+	p1, p2, err := decodePoints(curve, x1, x2)
+	if err != nil {
+		return nil, err
 	}
-	negY2 := new(big.Int).Neg(x2y)
-	if negY2.Sign() < 0 {
-		negY2.Add(negY2, curve.Params().P)
-	}
-	tx, ty := curve.Add(x1x, x1y, x2x, negY2)
-	return elliptic.MarshalCompressed(curve, tx, ty), nil
+	return p1.Subtract(p2).Encode(), nil
 }
 
 func PointsEqual(curve elliptic.Curve, x1 []byte, x2 []byte) bool {
-	x1x, x1y := elliptic.UnmarshalCompressed(curve, x1)
-	x2x, x2y := elliptic.UnmarshalCompressed(curve, x2)
-	if x1x == nil || x1y == nil || x2x == nil || x2y == nil {
+	p1, p2, err := decodePoints(curve, x1, x2)
+	if err != nil {
 		return false
 	}
-	return x1x.Cmp(x2x) == 0 && x1y.Cmp(x2y) == 0
+	return p1.Equal(p2)
+}
+
+func decodePoints(curve elliptic.Curve, x1 []byte, x2 []byte) (*Point, *Point, error) {
+	p1, err1 := DecodePoint(curve, x1)
+	p2, err2 := DecodePoint(curve, x2)
+	if err1 != nil || err2 != nil {
+		return nil, nil, errors.New("one or more points are invalid")
+	}
+	return p1, p2, nil
+}
+
+// PointIsValid reports whether X decodes to a point on the curve.
+func PointIsValid(curve elliptic.Curve, X []byte) bool {
+	xX, xY, _ := decodePoint(curve, X)
+	return xX != nil && xY != nil
 }
 
 func IsInfinity(xX *big.Int, xY *big.Int) bool {
 	return xX == nil && xY == nil
 }
 
+// cofactorCurve is implemented by curves whose group has h·N points for a
+// cofactor h > 1, N being the order of the base point.
+type cofactorCurve interface {
+	Cofactor() *big.Int
+}
+
+// CalculateCofactor returns the cofactor h of curve, the number of points
+// divided by the order of the base point. It is 1 for the curves of
+// crypto/elliptic and secp256k1, a curve with a cofactor declares it with a
+// Cofactor method.
 func CalculateCofactor(curve elliptic.Curve) *big.Int {
-	order := curve.Params().N
-	totalPoints := new(big.Int).Set(order)
-	cofactor := new(big.Int).Div(totalPoints, order)
-	return cofactor
+	if c, ok := curve.(cofactorCurve); ok {
+		return new(big.Int).Set(c.Cofactor())
+	}
+	return big.NewInt(1)
 }
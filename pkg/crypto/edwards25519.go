@@ -0,0 +1,413 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"crypto/subtle"
+	"math/big"
+	"sync"
+)
+
+// -- edwards25519 (RFC 7748, RFC 8032), -x² + y² = 1 + d·x²·y² over
+// GF(2^255 - 19). The group has 8·L points, the base point generates the
+// subgroup of prime order L. It implements elliptic.Curve on the affine
+// (x, y), with (0, 0) standing for the identity (0, 1) as crypto/elliptic
+// expects. Points are encoded as in RFC 8032: the 32 byte little-endian y
+// with the sign of x in the top bit.
+//
+// The arithmetic uses the field of field.go and extended coordinates with
+// the formulas of Hisil, Wong, Carter and Dawson (add-2008-hwcd-3 and
+// dbl-2008-hwcd), which are complete on this curve. Scalar multiplication
+// uses a fixed 4 bit window with constant time table lookups, multiples of
+// the base point a precomputed table.
+
+type edwards25519Curve struct {
+	params *elliptic.CurveParams
+}
+
+var edwards25519 = &edwards25519Curve{params: &elliptic.CurveParams{
+	Name:    "edwards25519",
+	BitSize: 255,
+	P:       hexInt("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed"),
+	N:       hexInt("1000000000000000000000000000000014def9dea2f79cd65812631a5cf5d3ed"),
+	B:       hexInt("52036cee2b6ffe738cc740797779e89800700a4d4141d8ab75eb4dca135978a3"),
+	Gx:      hexInt("216936d3cd6e53fec0a4e231fdd6dc5c692cc7609525a7b2c9562d608f25d51a"),
+	Gy:      hexInt("6666666666666666666666666666666666666666666666666666666666666658"),
+}}
+
+// Edwards25519 returns the edwards25519 group. Its Params hold d in B and
+// must not be used for arithmetic, the methods of CurveParams assume a
+// short Weierstrass curve with a = -3.
+func Edwards25519() elliptic.Curve {
+	return edwards25519
+}
+
+// edField is GF(2^255 - 19), 2^256 = 38 (mod p).
+var edField = newField(
+	felem{0xffffffffffffffed, 0xffffffffffffffff, 0xffffffffffffffff, 0x7fffffffffffffff},
+	38,
+)
+
+var (
+	edD      = felem{0x75eb4dca135978a3, 0x00700a4d4141d8ab, 0x8cc740797779e898, 0x52036cee2b6ffe73}
+	ed2D     = felem{0xebd69b9426b2f159, 0x00e0149a8283b156, 0x198e80f2eef3d130, 0x2406d9dc56dffce7}
+	edSqrtM1 = felem{0xc4ee1b274a0ea0b0, 0x2f431806ad2fe478, 0x2b4d00993dfbd7a7, 0x2b8324804fc1df0b}
+
+	// -- (p - 5) / 8, the exponent of the square root
+	edSqrtExp = felem{0xfffffffffffffffd, 0xffffffffffffffff, 0xffffffffffffffff, 0x0fffffffffffffff}
+)
+
+// edPoint is (X : Y : Z : T) for the affine (X/Z, Y/Z), with T = X·Y/Z.
+type edPoint struct {
+	x, y, z, t felem
+}
+
+var edIdentity = edPoint{y: felemOne, z: felemOne}
+
+// edAdd is add-2008-hwcd-3.
+func edAdd(p, q *edPoint) edPoint {
+	f := edField
+	a := f.sub(&p.y, &p.x)
+	b := f.sub(&q.y, &q.x)
+	a = f.mul(&a, &b)
+	b = f.add(&p.y, &p.x)
+	c := f.add(&q.y, &q.x)
+	b = f.mul(&b, &c)
+	c = f.mul(&p.t, &ed2D)
+	c = f.mul(&c, &q.t)
+	d := f.mul(&p.z, &q.z)
+	d = f.add(&d, &d)
+	e := f.sub(&b, &a)
+	ff := f.sub(&d, &c)
+	g := f.add(&d, &c)
+	h := f.add(&b, &a)
+	return edPoint{f.mul(&e, &ff), f.mul(&g, &h), f.mul(&ff, &g), f.mul(&e, &h)}
+}
+
+// edDouble is dbl-2008-hwcd with a = -1.
+func edDouble(p *edPoint) edPoint {
+	f := edField
+	a := f.mul(&p.x, &p.x)
+	b := f.mul(&p.y, &p.y)
+	c := f.mul(&p.z, &p.z)
+	c = f.add(&c, &c)
+	e := f.add(&p.x, &p.y)
+	e = f.mul(&e, &e)
+	e = f.sub(&e, &a)
+	e = f.sub(&e, &b)
+	g := f.sub(&b, &a)
+	ff := f.sub(&g, &c)
+	zero := felem{}
+	h := f.sub(&zero, &a)
+	h = f.sub(&h, &b)
+	return edPoint{f.mul(&e, &ff), f.mul(&g, &h), f.mul(&ff, &g), f.mul(&e, &h)}
+}
+
+func edSelect(cond uint64, a, b *edPoint) edPoint {
+	return edPoint{
+		felemSelect(cond, &a.x, &b.x), felemSelect(cond, &a.y, &b.y),
+		felemSelect(cond, &a.z, &b.z), felemSelect(cond, &a.t, &b.t),
+	}
+}
+
+// edLookup returns table[index] reading every entry.
+func edLookup(table *[16]edPoint, index byte) edPoint {
+	selected := edIdentity
+	for i := range table {
+		hit := uint64(subtle.ConstantTimeByteEq(byte(i), index))
+		selected = edSelect(hit, &table[i], &selected)
+	}
+	return selected
+}
+
+// toEdPoint maps the affine (x, y) to a point, (0, 0) is the identity.
+func toEdPoint(x, y *big.Int) edPoint {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return edIdentity
+	}
+	f := edField
+	px, py := f.fromBig(x), f.fromBig(y)
+	return edPoint{px, py, felemOne, f.mul(&px, &py)}
+}
+
+func (p *edPoint) affine() (*big.Int, *big.Int) {
+	f := edField
+	zInv := f.invert(&p.z)
+	x, y := f.mul(&p.x, &zInv), f.mul(&p.y, &zInv)
+	if f.isZero(&x) && f.equal(&y, &felemOne) {
+		return new(big.Int), new(big.Int)
+	}
+	return f.toBig(&x), f.toBig(&y)
+}
+
+func (curve *edwards25519Curve) Params() *elliptic.CurveParams {
+	return curve.params
+}
+
+// Cofactor is 8, the group has points of order 2, 4 and 8.
+func (curve *edwards25519Curve) Cofactor() *big.Int {
+	return big.NewInt(8)
+}
+
+func (curve *edwards25519Curve) IsOnCurve(x, y *big.Int) bool {
+	p := curve.params.P
+	if x.Sign() < 0 || x.Cmp(p) >= 0 || y.Sign() < 0 || y.Cmp(p) >= 0 {
+		return false
+	}
+
+	// -- y² - x² = 1 + d·x²·y²
+	f := edField
+	fx, fy := f.fromBig(x), f.fromBig(y)
+	x2, y2 := f.mul(&fx, &fx), f.mul(&fy, &fy)
+	left := f.sub(&y2, &x2)
+	right := f.mul(&x2, &y2)
+	right = f.mul(&right, &edD)
+	right = f.add(&right, &felemOne)
+	return f.equal(&left, &right)
+}
+
+func (curve *edwards25519Curve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	p, q := toEdPoint(x1, y1), toEdPoint(x2, y2)
+	sum := edAdd(&p, &q)
+	return sum.affine()
+}
+
+func (curve *edwards25519Curve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+	p := toEdPoint(x1, y1)
+	double := edDouble(&p)
+	return double.affine()
+}
+
+// ScalarMult returns k·(x1, y1), k is big-endian and may exceed N.
+func (curve *edwards25519Curve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.Int) {
+	p := toEdPoint(x1, y1)
+	product := edScalarMult(&p, k)
+	return product.affine()
+}
+
+func edScalarMult(p *edPoint, k []byte) edPoint {
+	var table [16]edPoint
+	table[0] = edIdentity
+	table[1] = *p
+	for i := 2; i < 16; i++ {
+		table[i] = edAdd(&table[i-1], &table[1])
+	}
+
+	result := edIdentity
+	for _, b := range k {
+		for _, window := range [2]byte{b >> 4, b & 0xf} {
+			for i := 0; i < 4; i++ {
+				result = edDouble(&result)
+			}
+			selected := edLookup(&table, window)
+			result = edAdd(&result, &selected)
+		}
+	}
+	return result
+}
+
+// edTable holds j·16^i·P for the 64 windows i of a 32 byte scalar.
+type edTable [64][16]edPoint
+
+func newEdTable(p *edPoint) *edTable {
+	table := new(edTable)
+	base := *p
+	for i := range table {
+		table[i][0] = edIdentity
+		table[i][1] = base
+		for j := 2; j < 16; j++ {
+			table[i][j] = edAdd(&table[i][j-1], &base)
+		}
+		for j := 0; j < 4; j++ {
+			base = edDouble(&base)
+		}
+	}
+	return table
+}
+
+var edBaseTable = sync.OnceValue(func() *edTable {
+	base := toEdPoint(edwards25519.params.Gx, edwards25519.params.Gy)
+	return newEdTable(&base)
+})
+
+func (table *edTable) scalarMult(k []byte) edPoint {
+	if len(k) > 32 {
+		return edScalarMult(&table[0][1], k)
+	}
+
+	result := edIdentity
+	for i := 0; i < len(k); i++ {
+		// -- Byte i from the end holds windows 2i and 2i + 1
+		b := k[len(k)-1-i]
+		low := edLookup(&table[2*i], b&0xf)
+		high := edLookup(&table[2*i+1], b>>4)
+		result = edAdd(&result, &low)
+		result = edAdd(&result, &high)
+	}
+	return result
+}
+
+func (table *edTable) multiply(k []byte) element {
+	product := table.scalarMult(k)
+	return &product
+}
+
+// ScalarBaseMult returns k·G with the precomputed table, one addition per
+// 4 bits of k and no doubling.
+func (curve *edwards25519Curve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	product := edBaseTable().scalarMult(k)
+	return product.affine()
+}
+
+// -- edwards25519 as a group, see group.go
+
+func (curve *edwards25519Curve) element(x, y *big.Int) element {
+	p := toEdPoint(x, y)
+	return &p
+}
+
+func (curve *edwards25519Curve) baseMultiply(k []byte) element {
+	return edBaseTable().multiply(k)
+}
+
+func (curve *edwards25519Curve) newTable(p element) table {
+	return newEdTable(p.(*edPoint))
+}
+
+func (p *edPoint) add(q element) element {
+	sum := edAdd(p, q.(*edPoint))
+	return &sum
+}
+
+func (p *edPoint) double() element {
+	double := edDouble(p)
+	return &double
+}
+
+// negate returns (-X : Y : Z : -T).
+func (p *edPoint) negate() element {
+	f := edField
+	zero := felem{}
+	return &edPoint{f.sub(&zero, &p.x), p.y, p.z, f.sub(&zero, &p.t)}
+}
+
+func (p *edPoint) multiply(k []byte) element {
+	product := edScalarMult(p, k)
+	return &product
+}
+
+// isIdentity reports whether X = 0 and Y = Z.
+func (p *edPoint) isIdentity() bool {
+	return p.equal(&edIdentity)
+}
+
+// equal compares X1·Z2 with X2·Z1 and Y1·Z2 with Y2·Z1.
+func (p *edPoint) equal(q element) bool {
+	f := edField
+	o := q.(*edPoint)
+	x1, x2 := f.mul(&p.x, &o.z), f.mul(&o.x, &p.z)
+	y1, y2 := f.mul(&p.y, &o.z), f.mul(&o.y, &p.z)
+	x1, x2 = f.canonical(&x1), f.canonical(&x2)
+	y1, y2 = f.canonical(&y1), f.canonical(&y2)
+	var diff uint64
+	for i := range x1 {
+		diff |= x1[i] ^ x2[i] | y1[i] ^ y2[i]
+	}
+	return diff == 0
+}
+
+func (curve *edwards25519Curve) CompressedSize() int {
+	return 32
+}
+
+// MarshalCompressed is the encoding of RFC 8032 (section 5.1.2).
+func (curve *edwards25519Curve) MarshalCompressed(x, y *big.Int) []byte {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		y = big.NewInt(1)
+	}
+	encoded := make([]byte, 32)
+	y.FillBytes(encoded)
+	reverse(encoded)
+	encoded[31] |= byte(x.Bit(0)) << 7
+	return encoded
+}
+
+// UnmarshalCompressed is the decoding of RFC 8032 (section 5.1.3), it
+// rejects the non-canonical encodings: y ≥ p and x = 0 with the sign bit
+// set.
+func (curve *edwards25519Curve) UnmarshalCompressed(data []byte) (*big.Int, *big.Int) {
+	if len(data) != 32 {
+		return nil, nil
+	}
+	encoded := append([]byte(nil), data...)
+	sign := encoded[31] >> 7
+	encoded[31] &= 0x7f
+	reverse(encoded)
+
+	y := new(big.Int).SetBytes(encoded)
+	if y.Cmp(curve.params.P) >= 0 {
+		return nil, nil
+	}
+
+	// -- x² = u / v with u = y² - 1 and v = d·y² + 1
+	f := edField
+	fy := f.fromBig(y)
+	y2 := f.mul(&fy, &fy)
+	u := f.sub(&y2, &felemOne)
+	v := f.mul(&edD, &y2)
+	v = f.add(&v, &felemOne)
+
+	// -- x = u·v³·(u·v⁷)^((p - 5) / 8)
+	v3 := f.mul(&v, &v)
+	v3 = f.mul(&v3, &v)
+	v7 := f.mul(&v3, &v3)
+	v7 = f.mul(&v7, &v)
+	x := f.mul(&u, &v7)
+	x = f.exp(&x, &edSqrtExp)
+	x = f.mul(&x, &v3)
+	x = f.mul(&x, &u)
+
+	vx2 := f.mul(&x, &x)
+	vx2 = f.mul(&vx2, &v)
+	zero := felem{}
+	minusU := f.sub(&zero, &u)
+	switch {
+	case f.equal(&vx2, &u):
+	case f.equal(&vx2, &minusU):
+		x = f.mul(&x, &edSqrtM1)
+	default:
+		return nil, nil
+	}
+
+	bx := f.toBig(&x)
+	if bx.Sign() == 0 && sign == 1 {
+		return nil, nil
+	}
+	if byte(bx.Bit(0)) != sign {
+		bx.Sub(curve.params.P, bx)
+	}
+
+	if bx.Sign() == 0 && y.Cmp(big.NewInt(1)) == 0 {
+		return new(big.Int), new(big.Int)
+	}
+	return bx, y
+}
+
+// Unmarshal decodes 0x04 || x || y with big-endian coordinates, the form
+// elliptic.Marshal produces.
+func (curve *edwards25519Curve) Unmarshal(data []byte) (*big.Int, *big.Int) {
+	if len(data) != 65 || data[0] != 4 {
+		return nil, nil
+	}
+	x := new(big.Int).SetBytes(data[1:33])
+	y := new(big.Int).SetBytes(data[33:])
+	if !curve.IsOnCurve(x, y) {
+		return nil, nil
+	}
+	return x, y
+}
+
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
@@ -0,0 +1,198 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+// ed25519Vectors are the secret and public keys of RFC 8032 (section 7.1,
+// tests 1 to 3).
+var ed25519Vectors = []struct {
+	secret, public string
+}{
+	{"9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60", "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"},
+	{"4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb", "3d4017c3e843895a92b70aa74d1b7ebc9c982ccf2ec4968cc0cd55f12af4660c"},
+	{"c5aa8df43f9f837bedb7442f31dcb7b166d38535076f094b85ce3a2e0b4458f7", "fc51cd8e6218a1a38da47ed00230f0580816ed13ba3303ac5deb911548908025"},
+}
+
+// edwardsLowOrder are the encodings of the points of order 1, 2, 4 and 8.
+var edwardsLowOrder = []string{
+	"0100000000000000000000000000000000000000000000000000000000000000",
+	"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+	"0000000000000000000000000000000000000000000000000000000000000000",
+	"0000000000000000000000000000000000000000000000000000000000000080",
+	"26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc05",
+	"c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a",
+	"26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc85",
+	"c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac03fa",
+}
+
+// clampedScalar is the secret scalar of an RFC 8032 private key.
+func clampedScalar(secret []byte) *big.Int {
+	h := sha512.Sum512(secret)
+	a := h[:32]
+	a[0] &= 248
+	a[31] &= 127
+	a[31] |= 64
+	reverse(a)
+	return new(big.Int).SetBytes(a)
+}
+
+func mustHex(t testing.TB, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestEdwards25519RFC8032(t *testing.T) {
+	curve := Edwards25519()
+	for _, vector := range ed25519Vectors {
+		got := MultiplyG(curve, clampedScalar(mustHex(t, vector.secret)))
+		if hex.EncodeToString(got) != vector.public {
+			t.Errorf("public key of %s: got %x, want %s", vector.secret, got, vector.public)
+		}
+	}
+}
+
+func TestEdwards25519AgreesWithCryptoEd25519(t *testing.T) {
+	curve := Edwards25519()
+	G := BasePoint(curve)
+	for i := 0; i < 64; i++ {
+		seed := make([]byte, ed25519.SeedSize)
+		seed[0] = byte(i)
+		public := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+		a := clampedScalar(seed)
+
+		if got := MultiplyG(curve, a); !bytes.Equal(got, public) {
+			t.Errorf("seed %d: base point table gives %x, want %x", i, got, public)
+		}
+		if got := G.Multiply(a).Encode(); !bytes.Equal(got, public) {
+			t.Errorf("seed %d: ScalarMult gives %x, want %x", i, got, public)
+		}
+		if !G.Add(G).Multiply(a).Equal(G.Multiply(a).Add(G.Multiply(a))) {
+			t.Errorf("seed %d: (G + G)·a differs from G·a + G·a", i)
+		}
+	}
+}
+
+func TestEdwards25519Group(t *testing.T) {
+	curve := Edwards25519()
+	if CalculateCofactor(curve).Cmp(big.NewInt(8)) != 0 {
+		t.Error("cofactor is not 8")
+	}
+	if !BasePoint(curve).Multiply(curve.Params().N).IsIdentity() {
+		t.Error("L·G is not the identity")
+	}
+}
+
+func TestEdwards25519LowOrderPoints(t *testing.T) {
+	curve := Edwards25519()
+	for _, encoded := range edwardsLowOrder {
+		L, err := DecodePoint(curve, mustHex(t, encoded))
+		if err != nil {
+			t.Errorf("%s: %v", encoded, err)
+			continue
+		}
+		if !L.HasSmallOrder() {
+			t.Errorf("%s does not have small order", encoded)
+		}
+		if !L.IsIdentity() && L.InPrimeOrderSubgroup() {
+			t.Errorf("%s is in the prime order subgroup", encoded)
+		}
+		if got := hex.EncodeToString(L.Encode()); got != encoded {
+			t.Errorf("%s encodes back to %s", encoded, got)
+		}
+	}
+}
+
+func TestEdwards25519RejectsNonCanonicalEncodings(t *testing.T) {
+	curve := Edwards25519()
+	for _, encoded := range []string{
+		// -- y = p
+		"edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		// -- x = 0 with the sign bit set
+		"0100000000000000000000000000000000000000000000000000000000000080",
+	} {
+		if _, err := DecodePoint(curve, mustHex(t, encoded)); err == nil {
+			t.Errorf("%s decoded", encoded)
+		}
+	}
+}
+
+// smallOrder returns the order of a point of small order.
+func smallOrder(L *Point) int64 {
+	for order := int64(1); order < 8; order *= 2 {
+		if L.Multiply(big.NewInt(order)).IsIdentity() {
+			return order
+		}
+	}
+	return 8
+}
+
+// forgeZKP returns a proof for X = G·x + L, L of order order, that satisfies
+// the proof equation without knowing the logarithm of X: it draws nonces
+// until the challenge is a multiple of the order, then L·h vanishes.
+func forgeZKP(t *testing.T, X *Point, x *big.Int, order int64, prover string) SchnorrZKP {
+	t.Helper()
+	suite := LegacySuite(X.Curve())
+	G := BasePoint(X.Curve())
+	n := X.Curve().Params().N
+	for i := 0; i < 1000; i++ {
+		v := GenerateKey(X.Curve())
+		V := G.Multiply(v).Encode()
+		h, err := suite.zkpChallenge(G, V, X.Encode(), prover)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if new(big.Int).Mod(h, big.NewInt(order)).Sign() != 0 {
+			continue
+		}
+
+		r := ModuloN(new(big.Int).Sub(v, new(big.Int).Mul(x, h)), n)
+		if !G.Multiply(r).Add(X.Multiply(h)).Equal(G.Multiply(v)) {
+			t.Fatal("forged proof does not satisfy the proof equation")
+		}
+		return SchnorrZKP{V: V, R: r}
+	}
+	t.Fatal("no challenge was a multiple of the order")
+	return SchnorrZKP{}
+}
+
+// TestEdwards25519ZKPRejectsSmallOrder sends public keys of order 2, 4 and
+// 8, and keys with such a component, through VerifyZKP and VerifyZKPBatch
+// with proofs that satisfy the proof equation.
+func TestEdwards25519ZKPRejectsSmallOrder(t *testing.T) {
+	curve := Edwards25519()
+	G := BasePoint(curve)
+	for _, encoded := range edwardsLowOrder[1:] {
+		L, _ := DecodePoint(curve, mustHex(t, encoded))
+		order := smallOrder(L)
+		x := GenerateKey(curve)
+
+		for _, key := range []struct {
+			name string
+			X    *Point
+			x    *big.Int
+		}{
+			{"order", L, new(big.Int)},
+			{"torsioned", G.Multiply(x).Add(L), x},
+		} {
+			zkp := forgeZKP(t, key.X, key.x, order, "prover")
+			if VerifyZKP(curve, G.Encode(), key.X.Encode(), zkp, "prover") {
+				t.Errorf("%s %d (%s): VerifyZKP accepted", key.name, order, encoded)
+			}
+
+			statements := append(zkpStatements(curve, 2, 0), ZKPStatement{Generator: G, X: key.X, ZKP: zkp, Prover: "prover"})
+			if i, ok := VerifyZKPBatch(curve, statements); ok || i != 2 {
+				t.Errorf("%s %d (%s): VerifyZKPBatch got (%d, %v)", key.name, order, encoded, i, ok)
+			}
+		}
+	}
+}
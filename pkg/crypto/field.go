@@ -0,0 +1,184 @@
+package crypto
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// -- Arithmetic modulo a prime p < 2^256 for which r = 2^256 mod p fits in
+// 33 bits, edwards25519 has r = 38. Elements are little-endian 64 bit limbs
+// in [0, 2^256), only canonical reduces them below p. Nothing branches on
+// the value of an element.
+
+type felem [4]uint64
+
+var felemOne = felem{1}
+
+type field struct {
+	p felem
+	r uint64
+
+	// -- p - 2, the exponent of the inverse
+	invExp felem
+}
+
+func newField(p felem, r uint64) *field {
+	f := &field{p: p, r: r}
+	f.invExp = f.sub(&p, &felem{2})
+	return f
+}
+
+// fold adds carry·2^256, that is carry·r, to a.
+func (f *field) fold(a felem, carry uint64) felem {
+	hi, lo := bits.Mul64(carry, f.r)
+	var c uint64
+	a[0], c = bits.Add64(a[0], lo, 0)
+	a[1], c = bits.Add64(a[1], hi, c)
+	a[2], c = bits.Add64(a[2], 0, c)
+	a[3], c = bits.Add64(a[3], 0, c)
+
+	// -- A second carry leaves a below r, adding r again cannot carry
+	a[0], c = bits.Add64(a[0], c*f.r, 0)
+	a[1], c = bits.Add64(a[1], 0, c)
+	a[2], c = bits.Add64(a[2], 0, c)
+	a[3], _ = bits.Add64(a[3], 0, c)
+	return a
+}
+
+func (f *field) add(a, b *felem) felem {
+	var r felem
+	var c uint64
+	r[0], c = bits.Add64(a[0], b[0], 0)
+	r[1], c = bits.Add64(a[1], b[1], c)
+	r[2], c = bits.Add64(a[2], b[2], c)
+	r[3], c = bits.Add64(a[3], b[3], c)
+	return f.fold(r, c)
+}
+
+func (f *field) sub(a, b *felem) felem {
+	var r felem
+	var borrow uint64
+	r[0], borrow = bits.Sub64(a[0], b[0], 0)
+	r[1], borrow = bits.Sub64(a[1], b[1], borrow)
+	r[2], borrow = bits.Sub64(a[2], b[2], borrow)
+	r[3], borrow = bits.Sub64(a[3], b[3], borrow)
+
+	// -- The result wrapped by 2^256, take r off (twice if that wraps too)
+	for i := 0; i < 2; i++ {
+		r[0], borrow = bits.Sub64(r[0], borrow*f.r, 0)
+		r[1], borrow = bits.Sub64(r[1], 0, borrow)
+		r[2], borrow = bits.Sub64(r[2], 0, borrow)
+		r[3], borrow = bits.Sub64(r[3], 0, borrow)
+	}
+	return r
+}
+
+func (f *field) mul(a, b *felem) felem {
+	var t [8]uint64
+	for i := 0; i < 4; i++ {
+		var carry uint64
+		for j := 0; j < 4; j++ {
+			hi, lo := bits.Mul64(a[i], b[j])
+			var c uint64
+			lo, c = bits.Add64(lo, t[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			t[i+j] = lo
+			carry = hi
+		}
+		t[i+4] = carry
+	}
+
+	// -- t = lo + hi·2^256 = lo + hi·r
+	var r felem
+	var carry uint64
+	for i := 0; i < 4; i++ {
+		hi, lo := bits.Mul64(t[4+i], f.r)
+		var c uint64
+		lo, c = bits.Add64(lo, t[i], 0)
+		hi += c
+		lo, c = bits.Add64(lo, carry, 0)
+		hi += c
+		r[i] = lo
+		carry = hi
+	}
+	return f.fold(r, carry)
+}
+
+// exp returns a^e, e is public.
+func (f *field) exp(a *felem, e *felem) felem {
+	r := felemOne
+	for i := 3; i >= 0; i-- {
+		for bit := 63; bit >= 0; bit-- {
+			r = f.mul(&r, &r)
+			if (e[i]>>bit)&1 == 1 {
+				r = f.mul(&r, a)
+			}
+		}
+	}
+	return r
+}
+
+func (f *field) invert(a *felem) felem {
+	return f.exp(a, &f.invExp)
+}
+
+// canonical returns a reduced below p. Elements are below 2^256, which is
+// less than 3p.
+func (f *field) canonical(a *felem) felem {
+	r := *a
+	for i := 0; i < 2; i++ {
+		var reduced felem
+		var borrow uint64
+		reduced[0], borrow = bits.Sub64(r[0], f.p[0], 0)
+		reduced[1], borrow = bits.Sub64(r[1], f.p[1], borrow)
+		reduced[2], borrow = bits.Sub64(r[2], f.p[2], borrow)
+		reduced[3], borrow = bits.Sub64(r[3], f.p[3], borrow)
+		r = felemSelect(borrow, &r, &reduced)
+	}
+	return r
+}
+
+func (f *field) equal(a, b *felem) bool {
+	x, y := f.canonical(a), f.canonical(b)
+	return x == y
+}
+
+func (f *field) isZero(a *felem) bool {
+	return f.equal(a, &felem{})
+}
+
+// felemSelect returns a if cond is 1 and b if it is 0, in constant time.
+func felemSelect(cond uint64, a, b *felem) felem {
+	mask := -cond
+	var r felem
+	for i := range r {
+		r[i] = (a[i] & mask) | (b[i] &^ mask)
+	}
+	return r
+}
+
+// fromBig converts k, which must be in [0, 2^256).
+func (f *field) fromBig(k *big.Int) felem {
+	var buf [32]byte
+	k.FillBytes(buf[:])
+	var r felem
+	for i := range r {
+		for j := 0; j < 8; j++ {
+			r[i] |= uint64(buf[31-8*i-j]) << (8 * j)
+		}
+	}
+	return r
+}
+
+func (f *field) toBig(a *felem) *big.Int {
+	c := f.canonical(a)
+	var buf [32]byte
+	for i := range c {
+		for j := 0; j < 8; j++ {
+			buf[31-8*i-j] = byte(c[i] >> (8 * j))
+		}
+	}
+	return new(big.Int).SetBytes(buf[:])
+}
@@ -0,0 +1,148 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"sync"
+	"sync/atomic"
+)
+
+// MaxCachedPoints bounds the number of points kept by Precompute, once it
+// is reached a random cached point is evicted for every new one.
+var MaxCachedPoints = 1 << 14
+
+// MaxPointTables bounds the number of fixed-base tables kept by Precompute,
+// a table takes 96 KiB on secp256k1 and 128 KiB on edwards25519. Once it is
+// reached a random table is evicted for every new one.
+var MaxPointTables = 1 << 8
+
+// generators caches the compressed base point of every curve seen, keyed by
+// its *elliptic.CurveParams.
+var generators sync.Map
+
+// pointCache keeps the decoded coordinates of long-lived points (T, X3) so
+// they are not decompressed, a modular square root, on every use, and on
+// secp256k1 and edwards25519 their fixed-base tables. It is safe for
+// concurrent use.
+var pointCache = struct {
+	mutex  sync.RWMutex
+	points map[pointKey]*cachedPoint
+	tables map[pointKey]table
+}{points: make(map[pointKey]*cachedPoint), tables: make(map[pointKey]table)}
+
+// tableUses is how many times a point is registered with Precompute before
+// it gets a table. A table costs about as much as five multiplications and
+// saves two thirds of each.
+const tableUses = 8
+
+type pointKey struct {
+	curve   *elliptic.CurveParams
+	encoded string
+}
+
+type cachedPoint struct {
+	x, y *big.Int
+
+	// -- Number of times the point was registered
+	uses atomic.Uint32
+}
+
+// Precompute caches the decoded form of X, a point that will be used in
+// many handshakes (a users T, the servers X3). Later operations on the same
+// encoding skip the decompression. It returns false if X is not a point.
+//
+// On secp256k1 and edwards25519, a point registered repeatedly also gets a
+// fixed-base table (see MaxPointTables), its multiplications take a third
+// of the time. Building one costs about five multiplications, so a point
+// only gets one on its eighth registration. The curves of crypto/elliptic
+// do not accept tables for arbitrary points, their points only skip the
+// decompression.
+//
+// The curves base point needs no call: it is cached on first use, and every
+// multiplication of it goes through the curves ScalarBaseMult, which uses
+// the precomputed tables of crypto/elliptic or of this package.
+func Precompute(curve elliptic.Curve, X []byte) bool {
+	key := pointKey{curve.Params(), string(X)}
+	g, isGroup := curve.(group)
+
+	pointCache.mutex.RLock()
+	point, cached := pointCache.points[key]
+	pointCache.mutex.RUnlock()
+	if cached && (!isGroup || point.uses.Add(1) != tableUses) {
+		return true
+	}
+
+	if cached {
+		table := g.newTable(g.element(point.x, point.y))
+
+		pointCache.mutex.Lock()
+		defer pointCache.mutex.Unlock()
+		evictRandom(pointCache.tables, MaxPointTables)
+		pointCache.tables[key] = table
+		return true
+	}
+
+	x, y := elliptic.UnmarshalCompressed(curve, X)
+	if x == nil || y == nil {
+		return false
+	}
+
+	pointCache.mutex.Lock()
+	defer pointCache.mutex.Unlock()
+	if evicted, ok := evictRandom(pointCache.points, MaxCachedPoints); ok {
+		delete(pointCache.tables, evicted)
+	}
+	point = &cachedPoint{x: x, y: y}
+	point.uses.Store(1)
+	pointCache.points[key] = point
+	return true
+}
+
+// evictRandom deletes a random entry of cache if it holds limit or more and
+// returns its key.
+func evictRandom[V any](cache map[pointKey]V, limit int) (pointKey, bool) {
+	if len(cache) >= limit {
+		for evicted := range cache {
+			delete(cache, evicted)
+			return evicted, true
+		}
+	}
+	return pointKey{}, false
+}
+
+// decodePoint is elliptic.UnmarshalCompressed going through the cache of
+// Precompute, which also returns the table of the point if it has one. The
+// returned coordinates must not be modified.
+func decodePoint(curve elliptic.Curve, X []byte) (*big.Int, *big.Int, table) {
+	key := pointKey{curve.Params(), string(X)}
+	pointCache.mutex.RLock()
+	point, cached := pointCache.points[key]
+	table := pointCache.tables[key]
+	pointCache.mutex.RUnlock()
+	if cached {
+		return point.x, point.y, table
+	}
+
+	if isGenerator(curve, X) {
+		params := curve.Params()
+		return params.Gx, params.Gy, nil
+	}
+
+	x, y := elliptic.UnmarshalCompressed(curve, X)
+	return x, y, nil
+}
+
+func generator(curve elliptic.Curve) []byte {
+	params := curve.Params()
+	if g, ok := generators.Load(params); ok {
+		return g.([]byte)
+	}
+
+	g := marshalCompressed(curve, params.Gx, params.Gy)
+	generators.Store(params, g)
+	return g
+}
+
+func isGenerator(curve elliptic.Curve, X []byte) bool {
+	return string(X) == string(generator(curve))
+}
@@ -0,0 +1,74 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+)
+
+func TestFixedBaseTables(t *testing.T) {
+	for _, curve := range []elliptic.Curve{Secp256k1(), Edwards25519()} {
+		t.Run(curve.Params().Name, func(t *testing.T) {
+			G := BasePoint(curve)
+			k := GenerateKey(curve)
+
+			// -- G + 0 is not flagged as the base point and takes the
+			// variable-base path
+			variable := G.Add(identity(curve))
+			if !MultiplyBase(curve, k).Equal(variable.Multiply(k)) {
+				t.Error("the base point table disagrees with ScalarMult")
+			}
+
+			X := variable.Multiply(GenerateKey(curve)).Encode()
+			for i := 1; i < tableUses; i++ {
+				if !Precompute(curve, X) {
+					t.Fatal("Precompute rejected a point")
+				}
+			}
+			if P, _ := DecodePoint(curve, X); P.table != nil {
+				t.Errorf("a point registered %d times got a table", tableUses-1)
+			}
+			Precompute(curve, X)
+			P, _ := DecodePoint(curve, X)
+			if P.table == nil {
+				t.Fatalf("a point registered %d times got no table", tableUses)
+			}
+
+			want := newPoint(curve, P.x, P.y, nil)
+			for _, k := range []*big.Int{k, big.NewInt(0), big.NewInt(1), curve.Params().N, new(big.Int).Lsh(k, 200)} {
+				if !P.Multiply(k).Equal(want.Multiply(k)) {
+					t.Errorf("the table of P disagrees with ScalarMult for %x", k)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMultiply(b *testing.B) {
+	for _, id := range Curves {
+		curve, _ := id.Curve()
+		X := MultiplyBase(curve, GenerateKey(curve)).Encode()
+		for i := 0; i < tableUses; i++ {
+			Precompute(curve, X)
+		}
+		P, _ := DecodePoint(curve, X)
+		variable := newPoint(curve, P.x, P.y, nil)
+		k := GenerateKey(curve)
+
+		b.Run(id.String()+"/Base", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				MultiplyBase(curve, k)
+			}
+		})
+		b.Run(id.String()+"/Precomputed", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				P.Multiply(k)
+			}
+		})
+		b.Run(id.String()+"/Variable", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				variable.Multiply(k)
+			}
+		})
+	}
+}
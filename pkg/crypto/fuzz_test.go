@@ -0,0 +1,103 @@
+package crypto
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+// -- Native fuzz targets, run one with: go test -fuzz FuzzVerifyZKP ./pkg/crypto
+// The curve of an input is Curves[id % len(Curves)].
+
+func fuzzCurve(id uint8) CurveID {
+	return Curves[int(id)%len(Curves)]
+}
+
+// FuzzVerifyZKP checks that VerifyZKP and VerifyZKPBatch, which combines the
+// proofs on secp256k1, agree on every proof.
+func FuzzVerifyZKP(f *testing.F) {
+	// -- The valid proof each fuzzed proof is batched with
+	valid := make(map[CurveID]ZKPStatement)
+	for i, id := range Curves {
+		curve, _ := id.Curve()
+		statements := zkpStatements(curve, 1, 1)
+		for _, statement := range statements {
+			f.Add(uint8(i), statement.Generator.Encode(), statement.X.Encode(), statement.ZKP.V, statement.ZKP.R.Bytes(), statement.Prover)
+		}
+		valid[id] = statements[0]
+	}
+
+	f.Fuzz(func(t *testing.T, id uint8, G []byte, X []byte, V []byte, R []byte, prover string) {
+		curve, _ := fuzzCurve(id).Curve()
+		zkp := SchnorrZKP{V: V, R: new(big.Int).SetBytes(R)}
+		ok := VerifyZKP(curve, G, X, zkp, prover)
+
+		generator, err := DecodePoint(curve, G)
+		if err != nil {
+			return
+		}
+		XPoint, err := DecodePoint(curve, X)
+		if err != nil {
+			return
+		}
+
+		if ok != VerifyZKPPoint(generator, XPoint, zkp, prover) {
+			t.Fatal("VerifyZKP and VerifyZKPPoint disagree")
+		}
+
+		statements := []ZKPStatement{valid[fuzzCurve(id)], {Generator: generator, X: XPoint, ZKP: zkp, Prover: prover}}
+		i, batchOK := VerifyZKPBatch(curve, statements)
+		if batchOK != ok || (!ok && i != 1) {
+			t.Fatalf("VerifyZKP returned %v, VerifyZKPBatch (%d, %v)", ok, i, batchOK)
+		}
+	})
+}
+
+// FuzzDecodePoint checks that a point a codec accepts encodes to the same
+// bytes and can be computed with. The codec is PointCodecs[codec %
+// len(PointCodecs)].
+func FuzzDecodePoint(f *testing.F) {
+	for i, id := range Curves {
+		curve, _ := id.Curve()
+		P := MultiplyBase(curve, GenerateKey(curve))
+		for j, codec := range PointCodecs {
+			f.Add(uint8(i), uint8(j), codec.Encode(P))
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, id uint8, codecIndex uint8, data []byte) {
+		curve, _ := fuzzCurve(id).Curve()
+		codec := PointCodecs[int(codecIndex)%len(PointCodecs)]
+		P, err := codec.Decode(curve, data)
+		if err != nil {
+			return
+		}
+
+		if !bytes.Equal(codec.Encode(P), data) {
+			t.Fatal("point does not round trip")
+		}
+		P.Add(P).Multiply(big.NewInt(3)).Encode()
+	})
+}
+
+// FuzzDecodeScalar checks that a scalar the strict decoder accepts encodes
+// back to the same bytes.
+func FuzzDecodeScalar(f *testing.F) {
+	for i, id := range Curves {
+		curve, _ := id.Curve()
+		encoded, _ := EncodeScalar(curve, GenerateKey(curve))
+		f.Add(uint8(i), encoded)
+		f.Add(uint8(i), curve.Params().N.Bytes())
+	}
+
+	f.Fuzz(func(t *testing.T, id uint8, data []byte) {
+		curve, _ := fuzzCurve(id).Curve()
+		k, err := DecodeScalar(curve, data)
+		if err != nil {
+			return
+		}
+		if encoded, err := EncodeScalar(curve, k); err != nil || !bytes.Equal(encoded, data) {
+			t.Fatal("scalar does not round trip")
+		}
+	})
+}
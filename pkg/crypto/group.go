@@ -0,0 +1,53 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"math/big"
+)
+
+// group is implemented by the curves of this package, secp256k1 and
+// edwards25519. A Point of a group keeps the projective form its arithmetic
+// works on, a sum or a multiple is only normalised to affine coordinates,
+// a field inversion, when it is encoded. The curves of crypto/elliptic take
+// and return affine coordinates, their Points are affine.
+type group interface {
+	elliptic.Curve
+
+	// element maps the affine (x, y) to the group, (0, 0) is the identity.
+	element(x, y *big.Int) element
+
+	// baseMultiply returns G·k with a precomputed table, k is big-endian
+	// and may exceed N.
+	baseMultiply(k []byte) element
+
+	// newTable precomputes the multiples of p a fixed-base multiplication
+	// of p uses.
+	newTable(p element) table
+}
+
+// table holds j·16^i·P for the 64 windows i of a 32 byte scalar, a
+// multiplication of P takes one addition per 4 bits and no doubling. Longer
+// scalars fall back to a variable-base multiplication.
+type table interface {
+	// multiply returns P·k in constant time, k is big-endian.
+	multiply(k []byte) element
+}
+
+// element is a point of a group in projective coordinates. The arguments of
+// its methods are elements of the same group.
+type element interface {
+	add(q element) element
+	double() element
+	negate() element
+
+	// multiply returns p·k in constant time, k is big-endian and may exceed N.
+	multiply(k []byte) element
+
+	isIdentity() bool
+
+	// equal compares in constant time.
+	equal(q element) bool
+
+	// affine returns (x, y), (0, 0) for the identity.
+	affine() (*big.Int, *big.Int)
+}
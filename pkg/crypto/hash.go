@@ -2,7 +2,6 @@ package crypto
 
 import (
 	"crypto/ecdh"
-	"crypto/sha256"
 	"math/big"
 	"reflect"
 )
@@ -11,28 +10,42 @@ func IntTo4Bytes(i int) []byte {
 	return []byte{byte(i >> 24), byte(i >> 16), byte(i >> 8), byte(i)}
 }
 
+// Hash is the legacy transcript encoding (TranscriptLegacy), kept byte for
+// byte for the Java and TS implementations. Every value is prefixed with its
+// 4 byte length, with two quirks: a *big.Int gets an extra first byte, 0 if
+// its top bit is set and 1 otherwise (Java only adds a 0), and the R of a
+// SchnorrZKP gets none. Use a Transcript for anything new.
 func Hash(args ...interface{}) *big.Int {
-	sha256Output := sha256.New()
+	return HashWith(SHA256, args...)
+}
+
+// HashWith is Hash with another hash function.
+func HashWith(function HashFunction, args ...interface{}) *big.Int {
+	digest := function.New()
 
 	for _, arg := range args {
 		switch v := arg.(type) {
 
 		case *ecdh.PublicKey:
 			encoded := v.Bytes()
-			sha256Output.Write(IntTo4Bytes(len(encoded)))
-			sha256Output.Write(encoded)
+			digest.Write(IntTo4Bytes(len(encoded)))
+			digest.Write(encoded)
 
 		case []byte:
-			sha256Output.Write(IntTo4Bytes(len(v)))
-			sha256Output.Write(v)
+			digest.Write(IntTo4Bytes(len(v)))
+			digest.Write(v)
 
 		case string:
 			bytes := []byte(v)
-			sha256Output.Write(IntTo4Bytes(len(bytes)))
-			sha256Output.Write(bytes)
+			digest.Write(IntTo4Bytes(len(bytes)))
+			digest.Write(bytes)
 
 		case *big.Int:
 			i := v.Bytes()
+			// -- Zero has no bytes, hash it as a single zero byte
+			if len(i) == 0 {
+				i = []byte{0}
+			}
 			// I had the painfull joy of figuring out that in java, when
 			// why convert a big int into a byte array, the first byte is
 			// a sign byte. Adleast that's my guess.
@@ -41,30 +54,30 @@ func Hash(args ...interface{}) *big.Int {
 			} else {
 				i = append([]byte{1}, i...)
 			}
-			sha256Output.Write(IntTo4Bytes(len(i)))
-			sha256Output.Write(i)
+			digest.Write(IntTo4Bytes(len(i)))
+			digest.Write(i)
 
 		case *SchnorrZKP:
 			vEncoded := v.V
 			rBytes := v.R.Bytes()
-			sha256Output.Write(IntTo4Bytes(len(vEncoded)))
-			sha256Output.Write(vEncoded)
-			sha256Output.Write(IntTo4Bytes(len(rBytes)))
-			sha256Output.Write(rBytes)
+			digest.Write(IntTo4Bytes(len(vEncoded)))
+			digest.Write(vEncoded)
+			digest.Write(IntTo4Bytes(len(rBytes)))
+			digest.Write(rBytes)
 
 		case SchnorrZKP:
 			vEncoded := v.V
 			rBytes := v.R.Bytes()
-			sha256Output.Write(IntTo4Bytes(len(vEncoded)))
-			sha256Output.Write(vEncoded)
-			sha256Output.Write(IntTo4Bytes(len(rBytes)))
-			sha256Output.Write(rBytes)
+			digest.Write(IntTo4Bytes(len(vEncoded)))
+			digest.Write(vEncoded)
+			digest.Write(IntTo4Bytes(len(rBytes)))
+			digest.Write(rBytes)
 
 		default:
 			panic("Invalid type passed to Hash: " + reflect.TypeOf(v).String())
 		}
 	}
 
-	hash := sha256Output.Sum(nil)
+	hash := digest.Sum(nil)
 	return new(big.Int).SetBytes(hash[:])
 }
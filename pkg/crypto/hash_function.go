@@ -0,0 +1,80 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"hash"
+)
+
+var ErrUnsupportedHash = errors.New("unsupported hash function")
+
+// HashFunction selects the hash used by transcripts, KC tags and the key
+// schedule. The zero value is SHA-256, the hash of the legacy protocol.
+type HashFunction uint8
+
+const (
+	SHA256 HashFunction = iota
+	SHA384
+	SHA512
+	SHA3_256
+	SHA3_384
+	SHA3_512
+)
+
+// HashFunctions lists every supported HashFunction.
+var HashFunctions = []HashFunction{SHA256, SHA384, SHA512, SHA3_256, SHA3_384, SHA3_512}
+
+func (function HashFunction) Available() bool {
+	return function <= SHA3_512
+}
+
+// New returns a new hash.Hash, it panics if the function is not Available.
+func (function HashFunction) New() hash.Hash {
+	switch function {
+	case SHA256:
+		return sha256.New()
+	case SHA384:
+		return sha512.New384()
+	case SHA512:
+		return sha512.New()
+	case SHA3_256:
+		return newSHA3(32)
+	case SHA3_384:
+		return newSHA3(48)
+	case SHA3_512:
+		return newSHA3(64)
+	}
+	panic(ErrUnsupportedHash)
+}
+
+// Size is the length in bytes of the digests of function.
+func (function HashFunction) Size() int {
+	switch function {
+	case SHA256, SHA3_256:
+		return 32
+	case SHA384, SHA3_384:
+		return 48
+	case SHA512, SHA3_512:
+		return 64
+	}
+	panic(ErrUnsupportedHash)
+}
+
+func (function HashFunction) String() string {
+	switch function {
+	case SHA256:
+		return "SHA-256"
+	case SHA384:
+		return "SHA-384"
+	case SHA512:
+		return "SHA-512"
+	case SHA3_256:
+		return "SHA3-256"
+	case SHA3_384:
+		return "SHA3-384"
+	case SHA3_512:
+		return "SHA3-512"
+	}
+	return "unknown"
+}
@@ -0,0 +1,191 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"math/big"
+	"testing"
+)
+
+// hashVector holds the known answers of one hash function. The digests of
+// "abc" are the FIPS 180-4 / FIPS 202 examples, the HMACs are RFC 4231 test
+// case 2 (key "Jefe"). The other answers were computed with the standard
+// library: long is the digest of the bytes 0..199 (longer than the block of
+// every function), kcTag is DeriveHMACTagWith and legacyHash is HashWith
+// over the inputs below.
+type hashVector struct {
+	hash       HashFunction
+	abc        string
+	long       string
+	hmac       string
+	kcTag      string
+	legacyHash string
+}
+
+var hashVectors = []hashVector{
+	{
+		SHA256,
+		"ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		"1901da1c9f699b48f6b2636e65cbf73abf99d0441ef67f5c540a42f7051dec6f",
+		"5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		"6e38676642257e895fba0a4d8c84d067e00a287896d97ddcaa9a26962bd4b1f1",
+		"15a02623866c86365205efabed7d526d15476f876bb256ef439f091d5409b2a2",
+	},
+	{
+		SHA384,
+		"cb00753f45a35e8bb5a03d699ac65007272c32ab0eded1631a8b605a43ff5bed8086072ba1e7cc2358baeca134c825a7",
+		"7ea4bb2534c67036f49de7beb5fe8a2478df04ff3fef40a9cd4923999a590e9912df1297217ce1a021aa2fb1013498b8",
+		"af45d2e376484031617f78d2b58a6b1b9c7ef464f5a01b47e42ec3736322445e8e2240ca5e69e2c78b3239ecfab21649",
+		"aa6c552935882d7729f3dd8bd1909a32ab1e14b7cd2e5042656008b72ba9010885ef40c455606b8c271554c39b62727c",
+		"b1f323e42f7db49fadf0b9a8f5638644cf7a64126d4dcd9878c0eb7a1f9d79687ef62037110a96fdf525f706649e3e94",
+	},
+	{
+		SHA512,
+		"ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f",
+		"986058e9895e2c2ab8f9e8cbdf801db12a44842a56a91d5a4e87b1fc98b293722c4664142e42c3c551ff898646268cd92b84ed230b8c94bed7798d4f27cd7465",
+		"164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea2505549758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+		"0ce48fd7b6fe3c5e94c08b1cd59f67bc0a0b8f3fe105d4ca086bc392cf0ba70a988424b2cfe18856e614580a94036d89fb6488853b4936613d7496cab7a006a5",
+		"f3c4414f5e439d7e99caf62c0e90ea2122615ef7b7493458d4ccdd573ff4829ce20e97d3008438ffacefc275fea2fa356875d0068ea706cdaac503c3e8b7860d",
+	},
+	{
+		SHA3_256,
+		"3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532",
+		"5f728f63bf5ee48c77f453c0490398fa645b8d4c4e56be9a41cfec344d6ca899",
+		"c7d4072e788877ae3596bbb0da73b887c9171f93095b294ae857fbe2645e1ba5",
+		"3dbe53f0d67d732f7ba7e3999e0508d1d5db7cf8e5eb4bfdecc6e8bfdcb92bfb",
+		"16ddef10b72ee4a79085697422421065920db5971ea590c2b383623f1d1d3a58",
+	},
+	{
+		SHA3_384,
+		"ec01498288516fc926459f58e2c6ad8df9b473cb0fc08c2596da7cf0e49be4b298d88cea927ac7f539f1edf228376d25",
+		"b13febb1b3c54a7c6b69367f693a1d1f3145709b6ddef23ff15874133ea1fb9cfa48ee7ff4ec9aa987dea641e33ccdf7",
+		"f1101f8cbf9766fd6764d2ed61903f21ca9b18f57cf3e1a23ca13508a93243ce48c045dc007f26a21b3f5e0e9df4c20a",
+		"883b9bd73db58eb7702e141a0abf6ddc193d50f69bce1b93b0919dec1797a62aee1f868aad81831ac7378b7f09c29aa4",
+		"b20a12114b416b4d3791ae92ebee041f888fe1e124652e46a446848f58f92f658123da3ba79caa86dd62146ff4d994d9",
+	},
+	{
+		SHA3_512,
+		"b751850b1a57168a5693cd924b6b096e08f621827444f70d884f5d0240d2712e10e116e9192af3c91a7ec57647e3934057340b4cf408d5a56592f8274eec53f0",
+		"ea5d05f19348dd589793354793a15f37a73b4c0bb4e750b9a00757dfce2f8b65a64191bb9b137de00feef6474cfd47abf7880efbc51614a5715df12cfe0caee3",
+		"5a4bfeab6166427c7a3647b747292b8384537cdb89afb3bf5665e4c5e709350b287baec921fd7ca0ee7a0c31d022a95e1fc92ba9d77df883960275beb4e62024",
+		"39ddbc6888a972b6b268a3d4c1088e40905ee28e879a20606c67daf663eaaaf6d10c683810aa8019682798d4a463d220566c3bbbf3015d4f1b3d3fabc8834f11",
+		"1fc5bc1ad297963c32cc1d346e61621acc697c1f3c3cca34c70c4066fd6778fd6d8be2fccb4fe5b4075514da10caa675a9072c43bf209401dce39c1cf2567b25",
+	},
+}
+
+// expandVectors are the expand_message_xmd vectors of RFC 9380 (appendix
+// K.1, SHA-256, 32 bytes).
+var expandVectors = []struct {
+	msg  string
+	want string
+}{
+	{"", "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+	{"abc", "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+	{"abcdef0123456789", "eff31487c770a893cfb36f912fbfcbff40d5661771ca4b2cb4eafe524333f5c1"},
+}
+
+// hashToFieldVector is u[0] and u[1] of P256_XMD:SHA-256_SSWU_RO_ for the
+// empty message, RFC 9380 appendix J.1.1.
+var hashToFieldVector = [2]string{
+	"ad5342c66a6dd0ff080df1da0ea1c04b96e0330dd89406465eeba11582515009",
+	"8c0f1d43204bd6f6ea70ae8013070a1518b43873bcd850aafa0a9e220e2eea5a",
+}
+
+// framedKCTag is DeriveFramedHMACTag with SHA-256 over the KC tag inputs of
+// hashVectors, computed with Python's hmac module.
+const framedKCTag = "8a29e2df621ac5997d491cc297a72c9f69171b8a5ce07d7c9f0251406423e85b"
+
+func digest(h hash.Hash, data []byte) []byte {
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// rfc4231 is RFC 4231 test case 2 through DeriveHMACTagWith, whose fields
+// are written back to back.
+func rfc4231(hash HashFunction) []byte {
+	key := new(big.Int).SetBytes([]byte("Jefe"))
+	return DeriveHMACTagWith(hash, key, "what do ya want for nothing?", "", "", nil, nil, nil, nil)
+}
+
+func checkHex(t *testing.T, name string, got []byte, want string) {
+	t.Helper()
+	if hex.EncodeToString(got) != want {
+		t.Errorf("%s\n  got  %x\n  want %s", name, got, want)
+	}
+}
+
+func TestHashVectors(t *testing.T) {
+	long := make([]byte, 200)
+	for i := range long {
+		long[i] = byte(i)
+	}
+
+	for _, vector := range hashVectors {
+		t.Run(vector.hash.String(), func(t *testing.T) {
+			checkHex(t, "abc", digest(vector.hash.New(), []byte("abc")), vector.abc)
+			checkHex(t, "long", digest(vector.hash.New(), long), vector.long)
+
+			// -- Written in pieces that straddle the block boundaries
+			pieces := vector.hash.New()
+			for i := 0; i < len(long); i += 7 {
+				pieces.Write(long[i:min(i+7, len(long))])
+			}
+			checkHex(t, "long in pieces", pieces.Sum(nil), vector.long)
+
+			checkHex(t, "HMAC", rfc4231(vector.hash), vector.hmac)
+
+			kcTag := DeriveHMACTagWith(vector.hash, big.NewInt(0x01020304),
+				"KC_1_U", "alice", "server",
+				[]byte("X1"), []byte("X2"), []byte("X3"), []byte("X4"),
+			)
+			checkHex(t, "KC tag", kcTag, vector.kcTag)
+
+			suite := LegacySuite(elliptic.P256())
+			suite.Hash = vector.hash
+			if len(kcTag) != suite.HMACTagSize() || suite.HMACTagSize() != vector.hash.Size() {
+				t.Errorf("KC tag of %d bytes, HMACTagSize %d", len(kcTag), suite.HMACTagSize())
+			}
+
+			legacy := HashWith(vector.hash, "alice", []byte{1, 2, 3}, big.NewInt(0x7f))
+			checkHex(t, "legacy Hash", legacy.FillBytes(make([]byte, vector.hash.Size())), vector.legacyHash)
+		})
+	}
+}
+
+func TestExpandMessageXMD(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	for _, vector := range expandVectors {
+		uniform, err := ExpandMessageXMD(SHA256, []byte(vector.msg), dst, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkHex(t, fmt.Sprintf("expand_message_xmd %q", vector.msg), uniform, vector.want)
+	}
+
+	dst = []byte("QUUX-V01-CS02-with-P256_XMD:SHA-256_SSWU_RO_")
+	u, err := HashToField(SHA256, nil, dst, elliptic.P256().Params().P, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range u {
+		checkHex(t, fmt.Sprintf("hash_to_field u[%d]", i), u[i].FillBytes(make([]byte, 32)), hashToFieldVector[i])
+	}
+}
+
+func TestFramedHMACTag(t *testing.T) {
+	framed := DeriveFramedHMACTag(SHA256, big.NewInt(0x01020304),
+		"KC_1_U", "alice", "server",
+		[]byte("X1"), []byte("X2"), []byte("X3"), []byte("X4"),
+	)
+	checkHex(t, "framed KC tag", framed, framedKCTag)
+
+	// -- Moving a byte from one field to the next must change the framed tag
+	ab := DeriveFramedHMACTag(SHA256, big.NewInt(1), "ab", "c", "", nil, nil, nil, nil)
+	bc := DeriveFramedHMACTag(SHA256, big.NewInt(1), "a", "bc", "", nil, nil, nil, nil)
+	if bytes.Equal(ab, bc) {
+		t.Error("framed KC tag is ambiguous")
+	}
+}
@@ -0,0 +1,85 @@
+package crypto
+
+import (
+	"errors"
+	"math/big"
+)
+
+var ErrExpandLength = errors.New("expand_message_xmd output too long")
+
+// ExpandMessageXMD is expand_message_xmd of RFC 9380 (section 5.3.1), it
+// returns length uniformly random bytes derived from msg and the domain
+// separation tag dst. A dst longer than 255 bytes is hashed first, as the
+// RFC requires (section 5.3.3).
+func ExpandMessageXMD(function HashFunction, msg []byte, dst []byte, length int) ([]byte, error) {
+	h := function.New()
+	size, blockSize := h.Size(), h.BlockSize()
+
+	if len(dst) > 255 {
+		h.Write([]byte("H2C-OVERSIZE-DST-"))
+		h.Write(dst)
+		dst = h.Sum(nil)
+		h.Reset()
+	}
+
+	ell := (length + size - 1) / size
+	if ell > 255 || length > 65535 {
+		return nil, ErrExpandLength
+	}
+
+	dstPrime := append(append([]byte(nil), dst...), byte(len(dst)))
+
+	// -- b_0 = H(Z_pad || msg || I2OSP(length, 2) || I2OSP(0, 1) || DST_prime)
+	h.Write(make([]byte, blockSize))
+	h.Write(msg)
+	h.Write([]byte{byte(length >> 8), byte(length), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	// -- b_1 = H(b_0 || I2OSP(1, 1) || DST_prime)
+	h.Reset()
+	h.Write(b0)
+	h.Write([]byte{1})
+	h.Write(dstPrime)
+	b := h.Sum(nil)
+
+	uniform := make([]byte, 0, ell*size)
+	uniform = append(uniform, b...)
+
+	// -- b_i = H(strxor(b_0, b_(i-1)) || I2OSP(i, 1) || DST_prime)
+	for i := 2; i <= ell; i++ {
+		for j := range b {
+			b[j] ^= b0[j]
+		}
+		h.Reset()
+		h.Write(b)
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		b = h.Sum(nil)
+		uniform = append(uniform, b...)
+	}
+
+	return uniform[:length], nil
+}
+
+// HashToField is hash_to_field of RFC 9380 (section 5.2) with m = 1: it
+// returns count integers modulo modulus, each derived from L = ceil((bits +
+// k) / 8) bytes of expand_message_xmd where bits is the size of modulus and
+// the security parameter k is half of it. The bias of the reduction is
+// below 2^-k, unlike reducing a single digest.
+func HashToField(function HashFunction, msg []byte, dst []byte, modulus *big.Int, count int) ([]*big.Int, error) {
+	bits := modulus.BitLen()
+	L := (bits + bits/2 + 7) / 8
+
+	uniform, err := ExpandMessageXMD(function, msg, dst, count*L)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := make([]*big.Int, count)
+	for i := range elements {
+		element := new(big.Int).SetBytes(uniform[i*L : (i+1)*L])
+		elements[i] = element.Mod(element, modulus)
+	}
+	return elements, nil
+}
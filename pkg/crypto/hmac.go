@@ -2,10 +2,12 @@ package crypto
 
 import (
 	"crypto/hmac"
-	"crypto/sha256"
+	"errors"
 	"math/big"
 )
 
+var ErrInvalidTag = errors.New("KC tag has the wrong length")
+
 func DeriveHMACTag(
 	key *big.Int,
 	messageString string,
@@ -15,9 +17,24 @@ func DeriveHMACTag(
 	senderKey2 []byte,
 	receiverKey1 []byte,
 	receiverKey2 []byte,
-) *big.Int {
+) []byte {
+	return DeriveHMACTagWith(SHA256, key, messageString, senderID, receiverID, senderKey1, senderKey2, receiverKey1, receiverKey2)
+}
+
+// DeriveHMACTagWith is DeriveHMACTag with another hash function.
+func DeriveHMACTagWith(
+	function HashFunction,
+	key *big.Int,
+	messageString string,
+	senderID string,
+	receiverID string,
+	senderKey1 []byte,
+	senderKey2 []byte,
+	receiverKey1 []byte,
+	receiverKey2 []byte,
+) []byte {
 	keyBytes := key.Bytes()
-	mac := hmac.New(sha256.New, keyBytes)
+	mac := hmac.New(function.New, keyBytes)
 
 	mac.Write([]byte(messageString))
 	mac.Write([]byte(senderID))
@@ -27,8 +44,81 @@ func DeriveHMACTag(
 	mac.Write(receiverKey1)
 	mac.Write(receiverKey2)
 
-	hmacSum := mac.Sum(nil)
-	hmacBigInt := new(big.Int).SetBytes(hmacSum)
+	return mac.Sum(nil)
+}
+
+// DeriveFramedHMACTag is DeriveHMACTagWith with every field prefixed by its
+// 4 byte length, like DeriveMACWith. Unframed, ("ab", "c") and ("a", "bc")
+// give the same tag.
+func DeriveFramedHMACTag(
+	function HashFunction,
+	key *big.Int,
+	messageString string,
+	senderID string,
+	receiverID string,
+	senderKey1 []byte,
+	senderKey2 []byte,
+	receiverKey1 []byte,
+	receiverKey2 []byte,
+) []byte {
+	return DeriveMACWith(function, key,
+		[]byte(messageString), []byte(senderID), []byte(receiverID),
+		senderKey1, senderKey2, receiverKey1, receiverKey2,
+	)
+}
+
+// DeriveHMACTag is the KC tag of the suite: DeriveFramedHMACTag if it has
+// FramedTags, the legacy DeriveHMACTagWith otherwise.
+func (suite *Suite) DeriveHMACTag(
+	key *big.Int,
+	messageString string,
+	senderID string,
+	receiverID string,
+	senderKey1 []byte,
+	senderKey2 []byte,
+	receiverKey1 []byte,
+	receiverKey2 []byte,
+) []byte {
+	derive := DeriveHMACTagWith
+	if suite.FramedTags {
+		derive = DeriveFramedHMACTag
+	}
+	return derive(suite.Hash, key, messageString, senderID, receiverID, senderKey1, senderKey2, receiverKey1, receiverKey2)
+}
+
+// HMACTagSize is the length in bytes of the KC tags of the suite, a digest
+// of its hash function.
+func (suite *Suite) HMACTagSize() int {
+	return suite.Hash.Size()
+}
+
+// HMACTagsEqual compares two KC tags in constant time. A received tag of
+// another length than the expected one (Suite.HMACTagSize for
+// DeriveHMACTag) is refused.
+func HMACTagsEqual(expected []byte, received []byte) bool {
+	return len(received) == len(expected) && hmac.Equal(expected, received)
+}
+
+// HMACTagsEqualPadded is HMACTagsEqual for peers that encode tags like big
+// ints, without their leading zero bytes (the TS client): a received tag
+// shorter than the expected one is left-padded with zeros before comparing.
+// A longer one is still refused.
+func HMACTagsEqualPadded(expected []byte, received []byte) bool {
+	return HMACTagsEqual(expected, LeftPad(received, len(expected)))
+}
+
+// DeriveMAC computes an HMAC-SHA256 over the given fields, each field is
+// prefixed with its 4 byte length so the framing is unambiguous.
+func DeriveMAC(key *big.Int, fields ...[]byte) []byte {
+	return DeriveMACWith(SHA256, key, fields...)
+}
 
-	return hmacBigInt
+// DeriveMACWith is DeriveMAC with another hash function.
+func DeriveMACWith(function HashFunction, key *big.Int, fields ...[]byte) []byte {
+	mac := hmac.New(function.New, key.Bytes())
+	for _, field := range fields {
+		mac.Write(IntTo4Bytes(len(field)))
+		mac.Write(field)
+	}
+	return mac.Sum(nil)
 }
@@ -0,0 +1,228 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"crypto/subtle"
+	"errors"
+	"math/big"
+	"sync"
+)
+
+var ErrInvalidPoint = errors.New("point is invalid")
+
+// Point is a decoded curve point. Protocol code decodes the points it
+// receives once, does its arithmetic on Points and encodes the points it
+// sends once, instead of paying a decompression (a modular square root) and
+// a compression in every helper.
+//
+// On the curves of this package a Point holds the projective coordinates
+// its arithmetic works on (see group) and is normalised to affine
+// coordinates only when it is encoded. The curve implementations of
+// crypto/elliptic take and return affine coordinates, on them a Point is
+// affine and, like crypto/elliptic, (0, 0) is the identity. Points are
+// immutable and safe for concurrent use.
+type Point struct {
+	curve elliptic.Curve
+
+	// -- On a group the projective form, nil on the curves of crypto/elliptic
+	element element
+
+	// -- Affine coordinates, on a group computed from element the first
+	// time they are needed
+	once sync.Once
+	x, y *big.Int
+
+	// -- Whether the point is the base point, set for decoded points
+	base bool
+
+	// -- Fixed-base table of a point registered with Precompute, if any
+	table table
+
+	// -- Encoding the point was decoded from, if any
+	encoded []byte
+}
+
+// newPoint returns the point (x, y) of curve, encoded is its compressed
+// encoding if known.
+func newPoint(curve elliptic.Curve, x, y *big.Int, encoded []byte) *Point {
+	params := curve.Params()
+	p := &Point{curve: curve, x: x, y: y, encoded: encoded}
+	p.base = x.Cmp(params.Gx) == 0 && y.Cmp(params.Gy) == 0
+	if g, ok := curve.(group); ok {
+		p.element = g.element(x, y)
+	}
+	return p
+}
+
+// identity returns the identity of curve.
+func identity(curve elliptic.Curve) *Point {
+	return newPoint(curve, new(big.Int), new(big.Int), nil)
+}
+
+// coordinates returns the affine coordinates of p, normalising it on a
+// group. They must not be modified.
+func (p *Point) coordinates() (*big.Int, *big.Int) {
+	if p.element != nil {
+		p.once.Do(func() {
+			if p.x == nil {
+				p.x, p.y = p.element.affine()
+			}
+		})
+	}
+	return p.x, p.y
+}
+
+// encoder is implemented by curves with a point encoding of their own,
+// edwards25519 encodes its points as RFC 8032 does. Every other curve uses
+// the compressed SEC 1 encoding of crypto/elliptic. Decoding needs no
+// interface, elliptic.UnmarshalCompressed calls the UnmarshalCompressed
+// method of the curve if it has one.
+type encoder interface {
+	MarshalCompressed(x, y *big.Int) []byte
+	CompressedSize() int
+}
+
+func marshalCompressed(curve elliptic.Curve, x, y *big.Int) []byte {
+	if c, ok := curve.(encoder); ok {
+		return c.MarshalCompressed(x, y)
+	}
+	return elliptic.MarshalCompressed(curve, x, y)
+}
+
+// compressedSize is the length in bytes of a compressed point of curve.
+func compressedSize(curve elliptic.Curve) int {
+	if c, ok := curve.(encoder); ok {
+		return c.CompressedSize()
+	}
+	return 1 + (curve.Params().BitSize+7)/8
+}
+
+// DecodePoint decodes a compressed point, long-lived points registered with
+// Precompute are not decompressed again.
+func DecodePoint(curve elliptic.Curve, X []byte) (*Point, error) {
+	x, y, table := decodePoint(curve, X)
+	if x == nil || y == nil {
+		return nil, ErrInvalidPoint
+	}
+	p := newPoint(curve, x, y, X)
+	p.table = table
+	return p, nil
+}
+
+// BasePoint returns the base point of curve.
+func BasePoint(curve elliptic.Curve) *Point {
+	params := curve.Params()
+	return newPoint(curve, params.Gx, params.Gy, generator(curve))
+}
+
+// MultiplyBase returns G·k, using the curves ScalarBaseMult.
+func MultiplyBase(curve elliptic.Curve, k *big.Int) *Point {
+	if g, ok := curve.(group); ok {
+		return &Point{curve: curve, element: g.baseMultiply(k.Bytes())}
+	}
+	x, y := curve.ScalarBaseMult(k.Bytes())
+	return newPoint(curve, x, y, nil)
+}
+
+func (p *Point) Curve() elliptic.Curve {
+	return p.curve
+}
+
+// Encode returns the compressed encoding of p.
+func (p *Point) Encode() []byte {
+	if p.encoded != nil {
+		return append([]byte(nil), p.encoded...)
+	}
+	x, y := p.coordinates()
+	return marshalCompressed(p.curve, x, y)
+}
+
+func (p *Point) IsIdentity() bool {
+	if p.element != nil {
+		return p.element.isIdentity()
+	}
+	return p.x.Sign() == 0 && p.y.Sign() == 0
+}
+
+// InPrimeOrderSubgroup reports whether p·N is the identity, that is whether
+// p has no component in the small subgroup of a curve with a cofactor. Every
+// point of a curve with cofactor 1 is in it.
+func (p *Point) InPrimeOrderSubgroup() bool {
+	if CalculateCofactor(p.curve).Cmp(big.NewInt(1)) == 0 {
+		return true
+	}
+	return p.Multiply(p.curve.Params().N).IsIdentity()
+}
+
+// HasSmallOrder reports whether p·h is the identity, h being the cofactor.
+// The identity has small order.
+func (p *Point) HasSmallOrder() bool {
+	return p.ClearCofactor().IsIdentity()
+}
+
+// ClearCofactor returns p·h, which is in the prime order subgroup. It is p
+// on curves with cofactor 1.
+func (p *Point) ClearCofactor() *Point {
+	cofactor := CalculateCofactor(p.curve)
+	if cofactor.Cmp(big.NewInt(1)) == 0 {
+		return p
+	}
+	return p.Multiply(cofactor)
+}
+
+// Add returns p + q.
+func (p *Point) Add(q *Point) *Point {
+	if p.element != nil {
+		return &Point{curve: p.curve, element: p.element.add(q.element)}
+	}
+	x, y := p.curve.Add(p.x, p.y, q.x, q.y)
+	return newPoint(p.curve, x, y, nil)
+}
+
+// Subtract returns p - q.
+func (p *Point) Subtract(q *Point) *Point {
+	return p.Add(q.Negate())
+}
+
+// Negate returns -p.
+func (p *Point) Negate() *Point {
+	if p.element != nil {
+		return &Point{curve: p.curve, element: p.element.negate()}
+	}
+	if p.IsIdentity() {
+		return p
+	}
+	y := new(big.Int).Sub(p.curve.Params().P, p.y)
+	return newPoint(p.curve, p.x, y, nil)
+}
+
+// Multiply returns p·k, through ScalarBaseMult if p is the base point and
+// through its table if p was registered with Precompute.
+func (p *Point) Multiply(k *big.Int) *Point {
+	if p.base {
+		return MultiplyBase(p.curve, k)
+	}
+	if p.table != nil {
+		return &Point{curve: p.curve, element: p.table.multiply(k.Bytes())}
+	}
+	if p.element != nil {
+		return &Point{curve: p.curve, element: p.element.multiply(k.Bytes())}
+	}
+	x, y := p.curve.ScalarMult(p.x, p.y, k.Bytes())
+	return newPoint(p.curve, x, y, nil)
+}
+
+// Equal reports whether p and q are the same point, in constant time.
+func (p *Point) Equal(q *Point) bool {
+	if p.element != nil && q.element != nil {
+		return p.element.equal(q.element)
+	}
+
+	// -- For a given curve, both encodings have the same length
+	px, py := p.coordinates()
+	qx, qy := q.coordinates()
+	return subtle.ConstantTimeCompare(
+		elliptic.Marshal(p.curve, px, py),
+		elliptic.Marshal(q.curve, qx, qy),
+	) == 1
+}
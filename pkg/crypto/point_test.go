@@ -0,0 +1,67 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+)
+
+func TestPointArithmeticAgreesWithCurve(t *testing.T) {
+	for _, id := range Curves {
+		curve, _ := id.Curve()
+		t.Run(id.String(), func(t *testing.T) {
+			params := curve.Params()
+			G := BasePoint(curve)
+			a, b := big.NewInt(0x1234567), new(big.Int).Sub(params.N, big.NewInt(3))
+
+			x, y := curve.ScalarBaseMult(a.Bytes())
+			if !bytes.Equal(MultiplyBase(curve, a).Encode(), marshalCompressed(curve, x, y)) {
+				t.Error("G·a differs from ScalarBaseMult")
+			}
+
+			A, B := G.Multiply(a), G.Multiply(b)
+			x, y = curve.ScalarMult(x, y, b.Bytes())
+			if !bytes.Equal(A.Multiply(b).Encode(), marshalCompressed(curve, x, y)) {
+				t.Error("(G·a)·b differs from ScalarMult")
+			}
+
+			sum := new(big.Int).Add(a, b)
+			if !A.Add(B).Equal(G.Multiply(sum)) {
+				t.Error("G·a + G·b differs from G·(a + b)")
+			}
+			if !A.Add(A).Equal(G.Multiply(big.NewInt(2 * 0x1234567))) {
+				t.Error("G·a + G·a differs from G·2a")
+			}
+			if !A.Subtract(A).IsIdentity() || !A.Add(A.Negate()).IsIdentity() {
+				t.Error("G·a - G·a is not the identity")
+			}
+			if A.Equal(B) || A.Equal(identity(curve)) {
+				t.Error("distinct points compare equal")
+			}
+			if !identity(curve).Equal(A.Subtract(A)) {
+				t.Error("identities compare different")
+			}
+
+			// -- A computed point decodes back to an equal point
+			decoded, err := DecodePoint(curve, A.Add(B).Encode())
+			if err != nil || !decoded.Equal(A.Add(B)) {
+				t.Error("encoding does not round trip")
+			}
+		})
+	}
+}
+
+func TestGroupPointsStayProjective(t *testing.T) {
+	for _, curve := range []elliptic.Curve{Secp256k1(), Edwards25519()} {
+		G := BasePoint(curve)
+		P := G.Multiply(big.NewInt(7)).Add(G).Negate()
+		if P.x != nil {
+			t.Errorf("%s: arithmetic normalised the point", curve.Params().Name)
+		}
+		P.Encode()
+		if P.x == nil {
+			t.Errorf("%s: encoding did not normalise the point", curve.Params().Name)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+)
+
+var ErrInvalidScalar = errors.New("scalar is invalid")
+
+// ScalarSize is the length in bytes of the group order of curve, the width
+// of every encoded scalar.
+func ScalarSize(curve elliptic.Curve) int {
+	return (curve.Params().N.BitLen() + 7) / 8
+}
+
+// EncodeScalar returns k big-endian and exactly ScalarSize bytes wide, so
+// that a scalar with leading zero bytes keeps them. It fails with
+// ErrInvalidScalar unless k is in [0, N).
+func EncodeScalar(curve elliptic.Curve, k *big.Int) ([]byte, error) {
+	if !scalarInRange(curve, k) {
+		return nil, ErrInvalidScalar
+	}
+	return k.FillBytes(make([]byte, ScalarSize(curve))), nil
+}
+
+// DecodeScalar is the strict inverse of EncodeScalar: data must be exactly
+// ScalarSize bytes and encode a value below N. Every scalar has a single
+// encoding, so a peer cannot send a scalar in several forms.
+func DecodeScalar(curve elliptic.Curve, data []byte) (*big.Int, error) {
+	if len(data) != ScalarSize(curve) {
+		return nil, ErrInvalidScalar
+	}
+	k := new(big.Int).SetBytes(data)
+	if !scalarInRange(curve, k) {
+		return nil, ErrInvalidScalar
+	}
+	return k, nil
+}
+
+// scalarInRange reports whether k is in [0, N).
+func scalarInRange(curve elliptic.Curve, k *big.Int) bool {
+	return k != nil && k.Sign() >= 0 && k.Cmp(curve.Params().N) < 0
+}
+
+// Scalar is a scalar field of a payload, the EncodeScalar of its value. In
+// JSON it is base64 like any []byte. The curve is only known to the
+// receiver, which reads the value with Decode, so a scalar of N or more or
+// one that lost its leading zero bytes is refused there.
+type Scalar []byte
+
+// NewScalar encodes k with EncodeScalar.
+func NewScalar(curve elliptic.Curve, k *big.Int) (Scalar, error) {
+	encoded, err := EncodeScalar(curve, k)
+	return Scalar(encoded), err
+}
+
+// Decode returns the value of the scalar with the strict DecodeScalar.
+func (scalar Scalar) Decode(curve elliptic.Curve) (*big.Int, error) {
+	return DecodeScalar(curve, scalar)
+}
@@ -0,0 +1,113 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestScalarEncoding(t *testing.T) {
+	one, err := EncodeScalar(elliptic.P256(), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkHex(t, "P-256 scalar 1", one, "0000000000000000000000000000000000000000000000000000000000000001")
+
+	for _, id := range Curves {
+		curve, _ := id.Curve()
+		order := curve.Params().N
+		size := ScalarSize(curve)
+
+		// -- A scalar with a leading zero byte keeps its width
+		k := new(big.Int).Rsh(order, 9)
+		scalar, err := NewScalar(curve, k)
+		if err != nil || len(scalar) != size {
+			t.Fatalf("%s: scalar of %d bytes, %v", id, len(scalar), err)
+		}
+		if decoded, err := scalar.Decode(curve); err != nil || decoded.Cmp(k) != 0 {
+			t.Errorf("%s: scalar with a leading zero does not round trip", id)
+		}
+		encoded, err := B64EncodeScalar(curve, k)
+		if decoded, err2 := B64DecodeScalar(curve, encoded); err != nil || err2 != nil || decoded.Cmp(k) != 0 {
+			t.Errorf("%s: base64 scalar does not round trip", id)
+		}
+
+		for name, data := range map[string][]byte{
+			"N":     order.FillBytes(make([]byte, size)),
+			"short": k.Bytes(),
+			"long":  k.FillBytes(make([]byte, size+1)),
+		} {
+			if _, err := Scalar(data).Decode(curve); !errors.Is(err, ErrInvalidScalar) {
+				t.Errorf("%s: %s scalar decoded", id, name)
+			}
+		}
+
+		for _, k := range []*big.Int{order, new(big.Int).Add(order, big.NewInt(1)), big.NewInt(-1), nil} {
+			if _, err := EncodeScalar(curve, k); !errors.Is(err, ErrInvalidScalar) {
+				t.Errorf("%s: %v encoded", id, k)
+			}
+			if _, err := B64EncodeScalar(curve, k); !errors.Is(err, ErrInvalidScalar) {
+				t.Errorf("%s: %v encoded in base64", id, k)
+			}
+		}
+	}
+}
+
+func TestTagEncoding(t *testing.T) {
+	tag := make([]byte, SHA256.Size())
+	tag[len(tag)-1] = 1
+	decoded, err := B64DecodeTag(SHA256, B64EncodeBytes(tag))
+	if err != nil || !bytes.Equal(decoded, tag) {
+		t.Error("KC tag with leading zeros does not round trip")
+	}
+	if _, err := B64DecodeTag(SHA256, B64Encode(tag)); !errors.Is(err, ErrInvalidTag) {
+		t.Error("KC tag without its leading zeros decoded")
+	}
+
+	if !HMACTagsEqual(tag, tag) {
+		t.Error("equal tags compare different")
+	}
+	for name, received := range map[string][]byte{
+		"without its leading zeros": tag[len(tag)-1:],
+		"left-padded":               append(make([]byte, 1), tag...),
+		"truncated":                 tag[:len(tag)-1],
+		"empty":                     nil,
+	} {
+		if HMACTagsEqual(tag, received) {
+			t.Errorf("tag %s accepted", name)
+		}
+	}
+
+	// -- The padded comparison of the legacy peers only adds leading zeros
+	if !HMACTagsEqualPadded(tag, tag[len(tag)-1:]) || !HMACTagsEqualPadded(tag, tag) {
+		t.Error("tag without its leading zeros refused by HMACTagsEqualPadded")
+	}
+	for name, received := range map[string][]byte{
+		"left-padded": append(make([]byte, 1), tag...),
+		"truncated":   tag[:len(tag)-1],
+	} {
+		if HMACTagsEqualPadded(tag, received) {
+			t.Errorf("tag %s accepted by HMACTagsEqualPadded", name)
+		}
+	}
+}
+
+// TestZKPRejectsOversizedR checks that r + N, which satisfies the proof
+// equation, is refused.
+func TestZKPRejectsOversizedR(t *testing.T) {
+	curve := elliptic.P256()
+	n := curve.Params().N
+	G := BasePoint(curve)
+	x := big.NewInt(12345)
+	X := MultiplyBase(curve, x)
+	proof := GenerateZKPPoint(G, n, x, X.Encode(), "prover")
+	if !VerifyZKPPoint(G, X, *proof, "prover") {
+		t.Fatal("valid proof rejected")
+	}
+	oversized := SchnorrZKP{V: proof.V, R: new(big.Int).Add(proof.R, n)}
+	if VerifyZKPPoint(G, X, oversized, "prover") {
+		t.Error("proof with r + N accepted")
+	}
+}
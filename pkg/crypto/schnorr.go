@@ -17,8 +17,7 @@ func GenerateZKP(
 	X []byte,
 	userID string,
 ) *SchnorrZKP {
-	g := GetG(generator)
-	return GenerateZKPGProvided(generator, g, n, x, X, userID)
+	return GenerateZKPPoint(BasePoint(generator), n, x, X, userID)
 }
 
 func GenerateZKPGProvided(
@@ -29,15 +28,42 @@ func GenerateZKPGProvided(
 	X []byte,
 	prover string,
 ) *SchnorrZKP {
-	v := GenerateKey(curve)
-	V, err := MultiplyPoint(curve, &g, v)
+	G, err := DecodePoint(curve, g)
 	if err != nil {
 		panic(err)
 	}
-	h := Hash(g, V, X, prover)
+	return GenerateZKPPoint(G, n, x, X, prover)
+}
+
+// GenerateZKPPoint is GenerateZKPGProvided with a decoded generator.
+func GenerateZKPPoint(
+	g *Point,
+	n *big.Int,
+	x *big.Int,
+	X []byte,
+	prover string,
+) *SchnorrZKP {
+	return LegacySuite(g.Curve()).GenerateZKP(g, x, X, prover)
+}
+
+// GenerateZKP proves knowledge of x in X = g·x, X is the encoding of g·x in
+// the codec of the suite.
+func (suite *Suite) GenerateZKP(
+	g *Point,
+	x *big.Int,
+	X []byte,
+	prover string,
+) *SchnorrZKP {
+	v := GenerateKey(g.Curve())
+	V := suite.Codec.Encode(g.Multiply(v))
+	h, err := suite.zkpChallenge(g, V, X, prover)
+	if err != nil {
+		// -- V and X were encoded here or by the caller, never invalid
+		panic(err)
+	}
 	r := Multiply(x, h)
 	r = new(big.Int).Sub(v, r)
-	r = ModuloN(r, n)
+	r = ModuloN(r, suite.Curve.Params().N)
 	return &SchnorrZKP{V, r}
 }
 
@@ -48,52 +74,83 @@ func VerifyZKP(
 	zkp SchnorrZKP,
 	prover string,
 ) bool {
-	h := Hash(generator, zkp.V, X, prover)
-
-	if X == nil || zkp.V == nil || zkp.R == nil {
+	g, err := DecodePoint(curve, generator)
+	if err != nil {
 		return false
 	}
 
-	xX, xY := elliptic.UnmarshalCompressed(curve, X)
-	if IsInfinity(xX, xY) {
+	XPoint, err := DecodePoint(curve, X)
+	if err != nil {
 		return false
 	}
 
-	if xX.Cmp(big.NewInt(0)) == -1 || xX.Cmp(new(big.Int).Sub(curve.Params().N, big.NewInt(1))) == 1 {
+	return VerifyZKPPoint(g, XPoint, zkp, prover)
+}
+
+// VerifyZKPPoint is VerifyZKP with a decoded generator and public key.
+func VerifyZKPPoint(
+	generator *Point,
+	X *Point,
+	zkp SchnorrZKP,
+	prover string,
+) bool {
+	if X == nil {
 		return false
 	}
+	return LegacySuite(X.Curve()).VerifyZKP(generator, X, zkp, prover)
+}
 
-	if xY.Cmp(big.NewInt(0)) == -1 || xY.Cmp(new(big.Int).Sub(curve.Params().N, big.NewInt(1))) == 1 {
+// VerifyZKP checks a proof of knowledge of the discrete logarithm of X to
+// the base generator.
+func (suite *Suite) VerifyZKP(
+	generator *Point,
+	X *Point,
+	zkp SchnorrZKP,
+	prover string,
+) bool {
+	if generator == nil || !zkpPublicKeyValid(X, zkp) {
 		return false
 	}
 
-	if !curve.IsOnCurve(xX, xY) {
+	V, err := suite.Codec.Decode(X.Curve(), zkp.V)
+	if err != nil {
 		return false
 	}
 
-	xXh, err := MultiplyPoint(curve, &X, CalculateCofactor(curve))
+	h, err := suite.zkpChallenge(generator, zkp.V, suite.Codec.Encode(X), prover)
 	if err != nil {
 		return false
 	}
-	xXhX, xXhY := elliptic.UnmarshalCompressed(curve, xXh)
-	if IsInfinity(xXhX, xXhY) {
+
+	gRXh := generator.Multiply(zkp.R).Add(X.Multiply(h))
+	return V.Equal(gRXh)
+}
+
+// zkpPublicKeyValid performs the checks made on X and the proof fields
+// before the proof equation is evaluated: R must be in [0, N), r + N would
+// verify as well, and X must be a point of the prime order subgroup other
+// than the identity. On a curve with a
+// cofactor, X + L for a point L of small order would otherwise verify for
+// every challenge that is a multiple of the order of L.
+func zkpPublicKeyValid(X *Point, zkp SchnorrZKP) bool {
+	if X == nil || zkp.V == nil || !scalarInRange(X.Curve(), zkp.R) {
 		return false
 	}
 
-	gr, err := MultiplyPoint(curve, &generator, zkp.R)
-	if err != nil {
+	if X.IsIdentity() {
 		return false
 	}
 
-	Xhn, err := MultiplyPoint(curve, &X, ModuloN(h, curve.Params().N))
-	if err != nil {
+	curve := X.Curve()
+	p := curve.Params().P
+	x, y := X.coordinates()
+	if x.Sign() < 0 || x.Cmp(p) >= 0 || y.Sign() < 0 || y.Cmp(p) >= 0 {
 		return false
 	}
 
-	gRxhmn, err := AddPoints(curve, gr, Xhn)
-	if err != nil {
+	if !curve.IsOnCurve(x, y) {
 		return false
 	}
 
-	return PointsEqual(curve, zkp.V, gRxhmn)
+	return X.InPrimeOrderSubgroup()
 }
@@ -0,0 +1,548 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"crypto/subtle"
+	"math/big"
+	"math/bits"
+	"sync"
+)
+
+// -- secp256k1 (SEC 2, section 2.4.1). crypto/elliptic only implements
+// curves with a = -3, secp256k1 has a = 0 so it gets its own arithmetic:
+// field elements are four 64 bit limbs reduced with 2^256 = 2^32 + 977
+// (mod P), points are projective and use the complete formulas of Renes,
+// Costello and Batina (ePrint 2015/1060, algorithms 7 and 9), which have no
+// special case for the identity or for doubling. Scalar multiplication uses
+// a fixed 4 bit window with constant time table lookups, multiples of the
+// base point a precomputed table.
+
+type secp256k1Curve struct {
+	params *elliptic.CurveParams
+}
+
+var secp256k1 = &secp256k1Curve{params: &elliptic.CurveParams{
+	Name:    "secp256k1",
+	BitSize: 256,
+	P:       hexInt("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f"),
+	N:       hexInt("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141"),
+	B:       big.NewInt(7),
+	Gx:      hexInt("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"),
+	Gy:      hexInt("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"),
+}}
+
+// Secp256k1 returns the secp256k1 curve, y² = x³ + 7. Its Params must not
+// be used for arithmetic, the methods of CurveParams assume a = -3.
+func Secp256k1() elliptic.Curve {
+	return secp256k1
+}
+
+func hexInt(s string) *big.Int {
+	k, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid hex constant " + s)
+	}
+	return k
+}
+
+// -- Field arithmetic modulo P. Elements are little-endian limbs in
+// [0, 2^256), only feCanonical reduces them below P.
+
+type fe [4]uint64
+
+// feR is 2^256 mod P.
+const feR = 0x1000003d1
+
+var (
+	feP       = fe{0xfffffffefffffc2f, 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff}
+	feOne     = fe{1}
+	feB3      = fe{21}
+	feInvExp  = fe{0xfffffffefffffc2d, 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff}
+	feSqrtExp = fe{0xffffffffbfffff0c, 0xffffffffffffffff, 0xffffffffffffffff, 0x3fffffffffffffff}
+)
+
+// feFold adds carry·2^256, that is carry·feR, to r.
+func feFold(r fe, carry uint64) fe {
+	hi, lo := bits.Mul64(carry, feR)
+	var c uint64
+	r[0], c = bits.Add64(r[0], lo, 0)
+	r[1], c = bits.Add64(r[1], hi, c)
+	r[2], c = bits.Add64(r[2], 0, c)
+	r[3], c = bits.Add64(r[3], 0, c)
+
+	// -- A second carry leaves r below feR, adding feR again cannot carry
+	r[0], c = bits.Add64(r[0], c*feR, 0)
+	r[1], c = bits.Add64(r[1], 0, c)
+	r[2], c = bits.Add64(r[2], 0, c)
+	r[3], _ = bits.Add64(r[3], 0, c)
+	return r
+}
+
+func feAdd(a, b *fe) fe {
+	var r fe
+	var c uint64
+	r[0], c = bits.Add64(a[0], b[0], 0)
+	r[1], c = bits.Add64(a[1], b[1], c)
+	r[2], c = bits.Add64(a[2], b[2], c)
+	r[3], c = bits.Add64(a[3], b[3], c)
+	return feFold(r, c)
+}
+
+func feSub(a, b *fe) fe {
+	var r fe
+	var borrow uint64
+	r[0], borrow = bits.Sub64(a[0], b[0], 0)
+	r[1], borrow = bits.Sub64(a[1], b[1], borrow)
+	r[2], borrow = bits.Sub64(a[2], b[2], borrow)
+	r[3], borrow = bits.Sub64(a[3], b[3], borrow)
+
+	// -- The result wrapped by 2^256, take feR off (twice if that wraps too)
+	for i := 0; i < 2; i++ {
+		r[0], borrow = bits.Sub64(r[0], borrow*feR, 0)
+		r[1], borrow = bits.Sub64(r[1], 0, borrow)
+		r[2], borrow = bits.Sub64(r[2], 0, borrow)
+		r[3], borrow = bits.Sub64(r[3], 0, borrow)
+	}
+	return r
+}
+
+func feMul(a, b *fe) fe {
+	var t [8]uint64
+	for i := 0; i < 4; i++ {
+		var carry uint64
+		for j := 0; j < 4; j++ {
+			hi, lo := bits.Mul64(a[i], b[j])
+			var c uint64
+			lo, c = bits.Add64(lo, t[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			t[i+j] = lo
+			carry = hi
+		}
+		t[i+4] = carry
+	}
+
+	// -- t = lo + hi·2^256 = lo + hi·feR
+	var r fe
+	var carry uint64
+	for i := 0; i < 4; i++ {
+		hi, lo := bits.Mul64(t[4+i], feR)
+		var c uint64
+		lo, c = bits.Add64(lo, t[i], 0)
+		hi += c
+		lo, c = bits.Add64(lo, carry, 0)
+		hi += c
+		r[i] = lo
+		carry = hi
+	}
+	return feFold(r, carry)
+}
+
+// feExp returns a^e, e is public.
+func feExp(a *fe, e *fe) fe {
+	r := feOne
+	for i := 3; i >= 0; i-- {
+		for bit := 63; bit >= 0; bit-- {
+			r = feMul(&r, &r)
+			if (e[i]>>bit)&1 == 1 {
+				r = feMul(&r, a)
+			}
+		}
+	}
+	return r
+}
+
+// feCanonical returns a reduced below P.
+func feCanonical(a *fe) fe {
+	var r fe
+	var borrow uint64
+	r[0], borrow = bits.Sub64(a[0], feP[0], 0)
+	r[1], borrow = bits.Sub64(a[1], feP[1], borrow)
+	r[2], borrow = bits.Sub64(a[2], feP[2], borrow)
+	r[3], borrow = bits.Sub64(a[3], feP[3], borrow)
+	return feSelect(borrow, a, &r)
+}
+
+// feSelect returns a if cond is 1 and b if it is 0, in constant time.
+func feSelect(cond uint64, a, b *fe) fe {
+	mask := -cond
+	var r fe
+	for i := range r {
+		r[i] = (a[i] & mask) | (b[i] &^ mask)
+	}
+	return r
+}
+
+func feEqual(a, b *fe) bool {
+	x, y := feCanonical(a), feCanonical(b)
+	return x == y
+}
+
+func feFromBig(k *big.Int) fe {
+	var buf [32]byte
+	k.FillBytes(buf[:])
+	var r fe
+	for i := range r {
+		for j := 0; j < 8; j++ {
+			r[i] |= uint64(buf[31-8*i-j]) << (8 * j)
+		}
+	}
+	return r
+}
+
+func feToBig(a *fe) *big.Int {
+	c := feCanonical(a)
+	var buf [32]byte
+	for i := range c {
+		for j := 0; j < 8; j++ {
+			buf[31-8*i-j] = byte(c[i] >> (8 * j))
+		}
+	}
+	return new(big.Int).SetBytes(buf[:])
+}
+
+// -- Points, (X : Y : Z) is the affine (X/Z, Y/Z) and (0 : 1 : 0) the
+// identity.
+
+type k1Point struct {
+	x, y, z fe
+}
+
+var k1Identity = k1Point{y: feOne}
+
+// k1Add is algorithm 7 of Renes, Costello and Batina (a = 0, b3 = 21).
+func k1Add(p, q *k1Point) k1Point {
+	t0 := feMul(&p.x, &q.x)
+	t1 := feMul(&p.y, &q.y)
+	t2 := feMul(&p.z, &q.z)
+	t3 := feAdd(&p.x, &p.y)
+	t4 := feAdd(&q.x, &q.y)
+	t3 = feMul(&t3, &t4)
+	t4 = feAdd(&t0, &t1)
+	t3 = feSub(&t3, &t4)
+	t4 = feAdd(&p.y, &p.z)
+	x3 := feAdd(&q.y, &q.z)
+	t4 = feMul(&t4, &x3)
+	x3 = feAdd(&t1, &t2)
+	t4 = feSub(&t4, &x3)
+	x3 = feAdd(&p.x, &p.z)
+	y3 := feAdd(&q.x, &q.z)
+	x3 = feMul(&x3, &y3)
+	y3 = feAdd(&t0, &t2)
+	y3 = feSub(&x3, &y3)
+	x3 = feAdd(&t0, &t0)
+	t0 = feAdd(&x3, &t0)
+	t2 = feMul(&feB3, &t2)
+	z3 := feAdd(&t1, &t2)
+	t1 = feSub(&t1, &t2)
+	y3 = feMul(&feB3, &y3)
+	x3 = feMul(&t4, &y3)
+	t2 = feMul(&t3, &t1)
+	x3 = feSub(&t2, &x3)
+	y3 = feMul(&y3, &t0)
+	t1 = feMul(&t1, &z3)
+	y3 = feAdd(&t1, &y3)
+	t0 = feMul(&t0, &t3)
+	z3 = feMul(&z3, &t4)
+	z3 = feAdd(&z3, &t0)
+	return k1Point{x3, y3, z3}
+}
+
+// k1Double is algorithm 9 of Renes, Costello and Batina (a = 0, b3 = 21).
+func k1Double(p *k1Point) k1Point {
+	t0 := feMul(&p.y, &p.y)
+	z3 := feAdd(&t0, &t0)
+	z3 = feAdd(&z3, &z3)
+	z3 = feAdd(&z3, &z3)
+	t1 := feMul(&p.y, &p.z)
+	t2 := feMul(&p.z, &p.z)
+	t2 = feMul(&feB3, &t2)
+	x3 := feMul(&t2, &z3)
+	y3 := feAdd(&t0, &t2)
+	z3 = feMul(&t1, &z3)
+	t1 = feAdd(&t2, &t2)
+	t2 = feAdd(&t1, &t2)
+	t0 = feSub(&t0, &t2)
+	y3 = feMul(&t0, &y3)
+	y3 = feAdd(&x3, &y3)
+	t1 = feMul(&p.x, &p.y)
+	x3 = feMul(&t0, &t1)
+	x3 = feAdd(&x3, &x3)
+	return k1Point{x3, y3, z3}
+}
+
+func k1Select(cond uint64, a, b *k1Point) k1Point {
+	return k1Point{feSelect(cond, &a.x, &b.x), feSelect(cond, &a.y, &b.y), feSelect(cond, &a.z, &b.z)}
+}
+
+// toK1Point maps the affine (x, y) to a point, (0, 0) is the identity.
+func toK1Point(x, y *big.Int) k1Point {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return k1Identity
+	}
+	return k1Point{feFromBig(x), feFromBig(y), feOne}
+}
+
+func (p *k1Point) affine() (*big.Int, *big.Int) {
+	zero := fe{}
+	if feEqual(&p.z, &zero) {
+		return new(big.Int), new(big.Int)
+	}
+	zInv := feExp(&p.z, &feInvExp)
+	x, y := feMul(&p.x, &zInv), feMul(&p.y, &zInv)
+	return feToBig(&x), feToBig(&y)
+}
+
+func (curve *secp256k1Curve) Params() *elliptic.CurveParams {
+	return curve.params
+}
+
+// polynomial returns x³ + 7 mod P.
+func (curve *secp256k1Curve) polynomial(x *big.Int) *big.Int {
+	y2 := new(big.Int).Mul(x, x)
+	y2.Mul(y2, x)
+	y2.Add(y2, curve.params.B)
+	return y2.Mod(y2, curve.params.P)
+}
+
+func (curve *secp256k1Curve) IsOnCurve(x, y *big.Int) bool {
+	p := curve.params.P
+	if x.Sign() < 0 || x.Cmp(p) >= 0 || y.Sign() < 0 || y.Cmp(p) >= 0 {
+		return false
+	}
+	y2 := new(big.Int).Mul(y, y)
+	return y2.Mod(y2, p).Cmp(curve.polynomial(x)) == 0
+}
+
+func (curve *secp256k1Curve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	p, q := toK1Point(x1, y1), toK1Point(x2, y2)
+	sum := k1Add(&p, &q)
+	return sum.affine()
+}
+
+func (curve *secp256k1Curve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+	p := toK1Point(x1, y1)
+	double := k1Double(&p)
+	return double.affine()
+}
+
+// ScalarMult returns k·(x1, y1), k is big-endian and may exceed N.
+func (curve *secp256k1Curve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.Int) {
+	p := toK1Point(x1, y1)
+	product := k1ScalarMult(&p, k)
+	return product.affine()
+}
+
+// ScalarBaseMult returns k·G with the precomputed table, one addition per
+// 4 bits of k and no doubling.
+func (curve *secp256k1Curve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	product := k1BaseTable().scalarMult(k)
+	return product.affine()
+}
+
+func k1ScalarMult(p *k1Point, k []byte) k1Point {
+	var table [16]k1Point
+	table[0] = k1Identity
+	table[1] = *p
+	for i := 2; i < 16; i++ {
+		table[i] = k1Add(&table[i-1], &table[1])
+	}
+
+	result := k1Identity
+	for _, b := range k {
+		for _, window := range [2]byte{b >> 4, b & 0xf} {
+			for i := 0; i < 4; i++ {
+				result = k1Double(&result)
+			}
+			selected := k1Lookup(&table, window)
+			result = k1Add(&result, &selected)
+		}
+	}
+	return result
+}
+
+// k1Lookup returns table[index] reading every entry, so the index is not
+// leaked.
+func k1Lookup(table *[16]k1Point, index byte) k1Point {
+	selected := k1Identity
+	for i := range table {
+		hit := uint64(subtle.ConstantTimeByteEq(byte(i), index))
+		selected = k1Select(hit, &table[i], &selected)
+	}
+	return selected
+}
+
+// k1Table holds j·16^i·P for the 64 windows i of a 32 byte scalar.
+type k1Table [64][16]k1Point
+
+func newK1Table(p *k1Point) *k1Table {
+	table := new(k1Table)
+	base := *p
+	for i := range table {
+		table[i][0] = k1Identity
+		table[i][1] = base
+		for j := 2; j < 16; j++ {
+			table[i][j] = k1Add(&table[i][j-1], &base)
+		}
+		for j := 0; j < 4; j++ {
+			base = k1Double(&base)
+		}
+	}
+	return table
+}
+
+var k1BaseTable = sync.OnceValue(func() *k1Table {
+	base := toK1Point(secp256k1.params.Gx, secp256k1.params.Gy)
+	return newK1Table(&base)
+})
+
+func (table *k1Table) scalarMult(k []byte) k1Point {
+	if len(k) > 32 {
+		return k1ScalarMult(&table[0][1], k)
+	}
+
+	result := k1Identity
+	for i := 0; i < len(k); i++ {
+		// -- Byte i from the end holds windows 2i and 2i + 1
+		b := k[len(k)-1-i]
+		low := k1Lookup(&table[2*i], b&0xf)
+		high := k1Lookup(&table[2*i+1], b>>4)
+		result = k1Add(&result, &low)
+		result = k1Add(&result, &high)
+	}
+	return result
+}
+
+func (table *k1Table) multiply(k []byte) element {
+	product := table.scalarMult(k)
+	return &product
+}
+
+// multiScalarMult returns Σ k_i·P_i with Straus' interleaved method: the
+// terms share one chain of doublings and each adds an entry of a 4 bit
+// window table of its point, zero windows are skipped. It runs in variable
+// time and must only be given public values. The scalars are 32 bytes,
+// big-endian.
+func (curve *secp256k1Curve) multiScalarMult(points []element, scalars [][]byte) element {
+	tables := make([][16]k1Point, len(points))
+	for i, point := range points {
+		tables[i][0] = k1Identity
+		tables[i][1] = *point.(*k1Point)
+		for j := 2; j < 16; j++ {
+			tables[i][j] = k1Add(&tables[i][j-1], &tables[i][1])
+		}
+	}
+
+	result := k1Identity
+	for position := 0; position < 64; position++ {
+		if position > 0 {
+			for i := 0; i < 4; i++ {
+				result = k1Double(&result)
+			}
+		}
+		for i := range scalars {
+			window := scalars[i][position/2] >> 4
+			if position%2 == 1 {
+				window = scalars[i][position/2] & 0xf
+			}
+			if window != 0 {
+				result = k1Add(&result, &tables[i][window])
+			}
+		}
+	}
+	return &result
+}
+
+// -- secp256k1 as a group, see group.go
+
+func (curve *secp256k1Curve) element(x, y *big.Int) element {
+	p := toK1Point(x, y)
+	return &p
+}
+
+func (curve *secp256k1Curve) baseMultiply(k []byte) element {
+	return k1BaseTable().multiply(k)
+}
+
+func (curve *secp256k1Curve) newTable(p element) table {
+	return newK1Table(p.(*k1Point))
+}
+
+func (p *k1Point) add(q element) element {
+	sum := k1Add(p, q.(*k1Point))
+	return &sum
+}
+
+func (p *k1Point) double() element {
+	double := k1Double(p)
+	return &double
+}
+
+func (p *k1Point) negate() element {
+	zero := fe{}
+	return &k1Point{p.x, feSub(&zero, &p.y), p.z}
+}
+
+func (p *k1Point) multiply(k []byte) element {
+	product := k1ScalarMult(p, k)
+	return &product
+}
+
+func (p *k1Point) isIdentity() bool {
+	zero := fe{}
+	return feEqual(&p.z, &zero)
+}
+
+// equal compares X1·Z2 with X2·Z1 and Y1·Z2 with Y2·Z1.
+func (p *k1Point) equal(q element) bool {
+	o := q.(*k1Point)
+	x1, x2 := feMul(&p.x, &o.z), feMul(&o.x, &p.z)
+	y1, y2 := feMul(&p.y, &o.z), feMul(&o.y, &p.z)
+	x1, x2 = feCanonical(&x1), feCanonical(&x2)
+	y1, y2 = feCanonical(&y1), feCanonical(&y2)
+	var diff uint64
+	for i := range x1 {
+		diff |= x1[i] ^ x2[i] | y1[i] ^ y2[i]
+	}
+	return diff == 0
+}
+
+// Unmarshal and UnmarshalCompressed are what elliptic.Unmarshal and
+// elliptic.UnmarshalCompressed call, their generic versions assume a = -3.
+func (curve *secp256k1Curve) Unmarshal(data []byte) (*big.Int, *big.Int) {
+	byteLen := (curve.params.BitSize + 7) / 8
+	if len(data) != 1+2*byteLen || data[0] != 4 {
+		return nil, nil
+	}
+	x := new(big.Int).SetBytes(data[1 : 1+byteLen])
+	y := new(big.Int).SetBytes(data[1+byteLen:])
+	if !curve.IsOnCurve(x, y) {
+		return nil, nil
+	}
+	return x, y
+}
+
+func (curve *secp256k1Curve) UnmarshalCompressed(data []byte) (*big.Int, *big.Int) {
+	byteLen := (curve.params.BitSize + 7) / 8
+	if len(data) != 1+byteLen || (data[0] != 2 && data[0] != 3) {
+		return nil, nil
+	}
+	p := curve.params.P
+	x := new(big.Int).SetBytes(data[1:])
+	if x.Cmp(p) >= 0 {
+		return nil, nil
+	}
+
+	// -- P = 3 mod 4, so the square root is y2^((P+1)/4)
+	y2 := feFromBig(curve.polynomial(x))
+	root := feExp(&y2, &feSqrtExp)
+	y := feToBig(&root)
+	if byte(y.Bit(0)) != data[0]&1 {
+		y.Sub(p, y)
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, nil
+	}
+	return x, y
+}
@@ -0,0 +1,80 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// secp256k1Multiples are the affine coordinates of 2·G and 3·G on
+// secp256k1.
+var secp256k1Multiples = []struct {
+	k    int64
+	x, y string
+}{
+	{2, "c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5", "1ae168fea63dc339a3c58419466ceaeef7f632653266d0e1236431a950cfe52a"},
+	{3, "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9", "388f7b0f632de8140fe337e62a37f3566500a99934c2231b6cb9fd7584b8e672"},
+}
+
+func TestSecp256k1KnownAnswers(t *testing.T) {
+	k1 := Secp256k1()
+	params := k1.Params()
+	for _, vector := range secp256k1Multiples {
+		name := fmt.Sprintf("%d·G", vector.k)
+		want := "04" + vector.x + vector.y
+		k := big.NewInt(vector.k)
+
+		x, y := k1.ScalarBaseMult(k.Bytes())
+		checkHex(t, name, elliptic.Marshal(k1, x, y), want)
+		x, y = k1.ScalarMult(params.Gx, params.Gy, k.Bytes())
+		checkHex(t, name+" by ScalarMult", elliptic.Marshal(k1, x, y), want)
+		checkHex(t, name+" by Point", CodecUncompressed.Encode(BasePoint(k1).Multiply(k)), want)
+
+		// -- Through the compressed encoding of crypto/elliptic
+		ux, uy := elliptic.UnmarshalCompressed(k1, elliptic.MarshalCompressed(k1, x, y))
+		if ux == nil || ux.Cmp(x) != 0 || uy.Cmp(y) != 0 {
+			t.Errorf("%s does not decompress", name)
+		}
+	}
+
+	dx, dy := k1.Double(params.Gx, params.Gy)
+	sx, sy := k1.Add(params.Gx, params.Gy, params.Gx, params.Gy)
+	checkHex(t, "G+G", elliptic.Marshal(k1, sx, sy), "04"+secp256k1Multiples[0].x+secp256k1Multiples[0].y)
+	if dx.Cmp(sx) != 0 || dy.Cmp(sy) != 0 {
+		t.Error("G+G is not 2·G")
+	}
+
+	if nx, ny := k1.ScalarBaseMult(params.N.Bytes()); nx.Sign() != 0 || ny.Sign() != 0 {
+		t.Error("N·G is not the identity")
+	}
+	if !BasePoint(k1).Multiply(params.N).IsIdentity() {
+		t.Error("N·G is not the identity by Point")
+	}
+}
+
+func TestCurveRegistry(t *testing.T) {
+	for _, id := range Curves {
+		curve, err := id.Curve()
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		if got, err := LookupCurve(curve); err != nil || got != id {
+			t.Errorf("curve %d looks up as %d (%v)", id, got, err)
+		}
+		if byName, err := CurveByName(id.String()); err != nil || byName != curve {
+			t.Errorf("%s is not found by name (%v)", id, err)
+		}
+	}
+
+	if _, err := LookupCurve(elliptic.P224()); err != ErrUnsupportedCurve {
+		t.Errorf("P-224: got %v, want ErrUnsupportedCurve", err)
+	}
+	copied := *elliptic.P256().Params()
+	if _, err := LookupCurve(&copied); err != ErrUnsupportedCurve {
+		t.Errorf("copy of the P-256 parameters: got %v, want ErrUnsupportedCurve", err)
+	}
+	if _, err := CurveID(255).Curve(); err != ErrUnsupportedCurve {
+		t.Errorf("curve 255: got %v, want ErrUnsupportedCurve", err)
+	}
+}
@@ -0,0 +1,130 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// -- SHA3 (FIPS 202). It lives here because crypto/sha3 needs Go 1.24 and
+// golang.org/x/crypto would be the modules first dependency.
+
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// -- Rotation offsets and lane order of the combined ρ and π steps
+var keccakRotations = [24]int{1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44}
+var keccakLanes = [24]int{10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1}
+
+func keccakF1600(a *[25]uint64) {
+	var c [5]uint64
+	for round := 0; round < 24; round++ {
+		// -- θ
+		for x := 0; x < 5; x++ {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := 0; x < 5; x++ {
+			d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				a[y+x] ^= d
+			}
+		}
+
+		// -- ρ and π
+		lane := a[1]
+		for i := 0; i < 24; i++ {
+			j := keccakLanes[i]
+			lane, a[j] = a[j], bits.RotateLeft64(lane, keccakRotations[i])
+		}
+
+		// -- χ
+		for y := 0; y < 25; y += 5 {
+			copy(c[:], a[y:y+5])
+			for x := 0; x < 5; x++ {
+				a[y+x] ^= ^c[(x+1)%5] & c[(x+2)%5]
+			}
+		}
+
+		// -- ι
+		a[0] ^= keccakRoundConstants[round]
+	}
+}
+
+// sha3Digest is the Keccak sponge with the SHA3 padding, it implements
+// hash.Hash.
+type sha3Digest struct {
+	state   [25]uint64
+	pending []byte
+	rate    int
+	size    int
+}
+
+func newSHA3(size int) hash.Hash {
+	return &sha3Digest{rate: 200 - 2*size, size: size}
+}
+
+func (digest *sha3Digest) absorb(block []byte, state *[25]uint64) {
+	for i := 0; i < digest.rate/8; i++ {
+		state[i] ^= binary.LittleEndian.Uint64(block[8*i:])
+	}
+	keccakF1600(state)
+}
+
+func (digest *sha3Digest) Write(data []byte) (int, error) {
+	written := len(data)
+	if len(digest.pending) > 0 {
+		missing := digest.rate - len(digest.pending)
+		if len(data) < missing {
+			digest.pending = append(digest.pending, data...)
+			return written, nil
+		}
+		digest.pending = append(digest.pending, data[:missing]...)
+		digest.absorb(digest.pending, &digest.state)
+		digest.pending = digest.pending[:0]
+		data = data[missing:]
+	}
+
+	for len(data) >= digest.rate {
+		digest.absorb(data[:digest.rate], &digest.state)
+		data = data[digest.rate:]
+	}
+
+	digest.pending = append(digest.pending, data...)
+	return written, nil
+}
+
+// Sum pads a copy of the state, so the digest can keep absorbing.
+func (digest *sha3Digest) Sum(in []byte) []byte {
+	state := digest.state
+	block := make([]byte, digest.rate)
+	copy(block, digest.pending)
+	block[len(digest.pending)] ^= 0x06
+	block[digest.rate-1] ^= 0x80
+	digest.absorb(block, &state)
+
+	// -- The rate is larger than the output, one squeeze is enough
+	out := make([]byte, 8*((digest.size+7)/8))
+	for i := range out[:len(out)/8] {
+		binary.LittleEndian.PutUint64(out[8*i:], state[i])
+	}
+	return append(in, out[:digest.size]...)
+}
+
+func (digest *sha3Digest) Reset() {
+	digest.state = [25]uint64{}
+	digest.pending = digest.pending[:0]
+}
+
+func (digest *sha3Digest) Size() int {
+	return digest.size
+}
+
+func (digest *sha3Digest) BlockSize() int {
+	return digest.rate
+}
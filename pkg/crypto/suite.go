@@ -0,0 +1,84 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+)
+
+// PurposeZKPChallenge is the purpose of the challenge of a Schnorr proof.
+const PurposeZKPChallenge = "zkp_challenge"
+
+// Suite is what a protocol version changes in the hashing done on a curve.
+// Schnorr proofs made under one Suite only verify under the same Suite.
+type Suite struct {
+	Curve      elliptic.Curve
+	Hash       HashFunction
+	Transcript TranscriptVersion
+
+	// Domain, if set, starts every transcript of the suite
+	Domain *Domain
+
+	// HashToField maps transcripts to scalars with RFC 9380 hash_to_field,
+	// the domain separation tag is Domain.Tag. It needs a Domain.
+	HashToField bool
+
+	// FramedTags length-prefixes the fields of the KC tags, see
+	// DeriveFramedHMACTag.
+	FramedTags bool
+
+	// Codec encodes the points of proofs and transcripts
+	Codec PointCodec
+}
+
+// Domain separates the hashes of a protocol, version and ciphersuite from
+// any other use of the same hash function. Together with the purpose of
+// each hash (the t of a password, a proof challenge, a session key, ...) it
+// is written at the start of the transcript, so values hashed for one
+// purpose can never be mistaken for another.
+type Domain struct {
+	Protocol    string
+	Version     uint8
+	Ciphersuite string
+}
+
+// Tag is the domain separation tag of hash_to_field, for example
+// "OWL-V03-P-256".
+func (domain *Domain) Tag() []byte {
+	return []byte(fmt.Sprintf("%s-V%02d-%s", domain.Protocol, domain.Version, domain.Ciphersuite))
+}
+
+// LegacySuite is the Suite of the original protocol, the one the free
+// functions (GenerateZKP, VerifyZKP, ...) use.
+func LegacySuite(curve elliptic.Curve) *Suite {
+	return &Suite{Curve: curve, Transcript: TranscriptLegacy}
+}
+
+// NewTranscript starts a transcript for purpose, which is only written if
+// the suite has a Domain.
+func (suite *Suite) NewTranscript(purpose string) *Transcript {
+	transcript := NewTranscript(suite.Transcript, suite.Curve, suite.Hash)
+	transcript.Codec(suite.Codec)
+	if suite.Domain != nil {
+		transcript.String("protocol", suite.Domain.Protocol)
+		transcript.Bytes("version", []byte{suite.Domain.Version})
+		transcript.String("ciphersuite", suite.Domain.Ciphersuite)
+		transcript.String("purpose", purpose)
+		if suite.HashToField {
+			transcript.HashToField(suite.Domain.Tag())
+		}
+	}
+	return transcript
+}
+
+// zkpChallenge is the challenge h of a Schnorr proof of X = g·x with
+// commitment V, a scalar modulo N.
+func (suite *Suite) zkpChallenge(g *Point, V []byte, X []byte, prover string) (*big.Int, error) {
+	transcript := suite.NewTranscript(PurposeZKPChallenge)
+	transcript.Point("G", suite.Codec.Encode(g))
+	transcript.Point("V", V)
+	transcript.Point("X", X)
+	transcript.String("prover", prover)
+
+	return transcript.SumScalar()
+}
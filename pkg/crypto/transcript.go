@@ -0,0 +1,191 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+)
+
+// ErrNonCanonical is returned for a value that has no canonical transcript
+// encoding: a scalar outside [0, N) or a point of the wrong length.
+var ErrNonCanonical = errors.New("value has no canonical transcript encoding")
+
+// TranscriptVersion selects how values are encoded before they are hashed.
+type TranscriptVersion uint8
+
+const (
+	// TranscriptLegacy is the encoding of Hash. It is what the Java and TS
+	// implementations produce, labels are ignored.
+	TranscriptLegacy TranscriptVersion = iota
+
+	// TranscriptV1 starts with the version byte and writes every value after
+	// its length-prefixed label. Scalars are big-endian and as wide as the
+	// group order, points are as wide as their encoding (see PointCodec),
+	// strings and byte slices are prefixed with their 4 byte length.
+	TranscriptV1
+)
+
+// Transcript accumulates labelled values and hashes them. Errors are
+// reported once, by Sum or SumScalar.
+type Transcript struct {
+	version  TranscriptVersion
+	curve    elliptic.Curve
+	function HashFunction
+	encoded  []byte
+	legacy   []interface{}
+	err      error
+
+	// dst, if set, makes SumScalar use hash_to_field with it as the tag
+	dst []byte
+
+	// codec is the encoding of the points added
+	codec PointCodec
+}
+
+func NewTranscript(version TranscriptVersion, curve elliptic.Curve, function HashFunction) *Transcript {
+	transcript := &Transcript{version: version, curve: curve, function: function}
+	if version != TranscriptLegacy {
+		transcript.encoded = []byte{byte(version)}
+	}
+	return transcript
+}
+
+// HashToField makes SumScalar map the transcript to a scalar with RFC 9380
+// hash_to_field under the domain separation tag dst, instead of reducing
+// its digest modulo N. It has no effect on the legacy encoding.
+func (transcript *Transcript) HashToField(dst []byte) {
+	transcript.dst = dst
+}
+
+// Codec sets the encoding of the points added, CodecCompressed by default.
+func (transcript *Transcript) Codec(codec PointCodec) {
+	transcript.codec = codec
+}
+
+func (transcript *Transcript) write(data []byte) {
+	transcript.encoded = append(transcript.encoded, data...)
+}
+
+func (transcript *Transcript) fail(err error) {
+	if transcript.err == nil {
+		transcript.err = err
+	}
+}
+
+func (transcript *Transcript) label(label string) {
+	transcript.write(IntTo4Bytes(len(label)))
+	transcript.write([]byte(label))
+}
+
+// Scalar adds k, which must be in [0, N) outside of the legacy encoding.
+func (transcript *Transcript) Scalar(label string, k *big.Int) {
+	if k == nil {
+		transcript.fail(ErrNonCanonical)
+		return
+	}
+
+	if transcript.version == TranscriptLegacy {
+		transcript.legacy = append(transcript.legacy, k)
+		return
+	}
+
+	if !scalarInRange(transcript.curve, k) {
+		transcript.fail(ErrNonCanonical)
+		return
+	}
+
+	transcript.label(label)
+	transcript.write(k.FillBytes(make([]byte, ScalarSize(transcript.curve))))
+}
+
+// Point adds the encoding of a point, in the codec of the transcript.
+func (transcript *Transcript) Point(label string, encoded []byte) {
+	if transcript.version == TranscriptLegacy {
+		transcript.legacy = append(transcript.legacy, encoded)
+		return
+	}
+
+	if len(encoded) != transcript.codec.Size(transcript.curve) {
+		transcript.fail(ErrNonCanonical)
+		return
+	}
+
+	transcript.label(label)
+	transcript.write(encoded)
+}
+
+func (transcript *Transcript) Bytes(label string, data []byte) {
+	if transcript.version == TranscriptLegacy {
+		transcript.legacy = append(transcript.legacy, data)
+		return
+	}
+
+	transcript.label(label)
+	transcript.write(IntTo4Bytes(len(data)))
+	transcript.write(data)
+}
+
+func (transcript *Transcript) String(label string, s string) {
+	if transcript.version == TranscriptLegacy {
+		transcript.legacy = append(transcript.legacy, s)
+		return
+	}
+
+	transcript.Bytes(label, []byte(s))
+}
+
+// ZKP adds both halves of a Schnorr proof, V as a point and R as a scalar.
+func (transcript *Transcript) ZKP(label string, zkp *SchnorrZKP) {
+	if zkp == nil || zkp.R == nil {
+		transcript.fail(ErrNonCanonical)
+		return
+	}
+
+	if transcript.version == TranscriptLegacy {
+		transcript.legacy = append(transcript.legacy, zkp)
+		return
+	}
+
+	transcript.Point(label+".V", zkp.V)
+	transcript.Scalar(label+".R", zkp.R)
+}
+
+// Sum returns the hash of the values added so far, it is not reduced.
+func (transcript *Transcript) Sum() (*big.Int, error) {
+	if transcript.err != nil {
+		return nil, transcript.err
+	}
+
+	if transcript.version == TranscriptLegacy {
+		return HashWith(transcript.function, transcript.legacy...), nil
+	}
+
+	digest := transcript.function.New()
+	digest.Write(transcript.encoded)
+	return new(big.Int).SetBytes(digest.Sum(nil)), nil
+}
+
+// SumScalar returns the values added so far hashed to a scalar in [0, N).
+// Unless HashToField was called this is Sum reduced modulo N, which is
+// biased for groups whose order is not close to a power of two and cannot
+// reach every scalar of orders larger than the digest.
+func (transcript *Transcript) SumScalar() (*big.Int, error) {
+	n := transcript.curve.Params().N
+	if transcript.dst == nil || transcript.version == TranscriptLegacy {
+		h, err := transcript.Sum()
+		if err != nil {
+			return nil, err
+		}
+		return ModuloN(h, n), nil
+	}
+
+	if transcript.err != nil {
+		return nil, transcript.err
+	}
+
+	scalars, err := HashToField(transcript.function, transcript.encoded, transcript.dst, n, 1)
+	if err != nil {
+		return nil, err
+	}
+	return scalars[0], nil
+}
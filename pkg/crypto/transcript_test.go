@@ -0,0 +1,87 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+)
+
+// legacyChallenge is the challenge of a Schnorr proof on P-256 with G, V =
+// 2·G, X = 3·G and prover "alice", modulo N, computed with the baseline
+// Hash(G, V, X, prover) the legacy suite must reproduce.
+const legacyChallenge = "b5cc6318abe5b040074c551e6e4204e287efcfa110eff1d46ba2c213eb9f2596"
+
+func TestLegacyZKPChallengeMatchesBaseline(t *testing.T) {
+	curve := elliptic.P256()
+	G := BasePoint(curve)
+	V := G.Multiply(big.NewInt(2))
+	X := G.Multiply(big.NewInt(3))
+
+	h, err := LegacySuite(curve).zkpChallenge(G, V.Encode(), X.Encode(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkHex(t, "legacy ZKP challenge", h.Bytes(), legacyChallenge)
+
+	// -- The proof a baseline prover makes with v = 2 and x = 3 verifies
+	golden, _ := new(big.Int).SetString(legacyChallenge, 16)
+	r := ModuloN(new(big.Int).Sub(big.NewInt(2), new(big.Int).Mul(big.NewInt(3), golden)), curve.Params().N)
+	if !VerifyZKP(curve, G.Encode(), X.Encode(), SchnorrZKP{V: V.Encode(), R: r}, "alice") {
+		t.Error("proof with the baseline challenge refused")
+	}
+}
+
+// TestDomainSeparation hashes the same values under different purposes,
+// versions, ciphersuites and hash functions and checks no two agree.
+func TestDomainSeparation(t *testing.T) {
+	curve := elliptic.P256()
+	G := BasePoint(curve)
+	V := G.Multiply(big.NewInt(2)).Encode()
+	X := G.Multiply(big.NewInt(3)).Encode()
+
+	domain := func(version uint8, ciphersuite string) *Domain {
+		return &Domain{Protocol: "OWL", Version: version, Ciphersuite: ciphersuite}
+	}
+	suites := map[string]*Suite{
+		"legacy":         LegacySuite(curve),
+		"v1":             {Curve: curve, Transcript: TranscriptV1},
+		"v2":             {Curve: curve, Transcript: TranscriptV1, Domain: domain(2, "P-256")},
+		"v3":             {Curve: curve, Transcript: TranscriptV1, Domain: domain(3, "P-256"), HashToField: true},
+		"v4":             {Curve: curve, Transcript: TranscriptV1, Domain: domain(4, "P-256"), HashToField: true},
+		"v2 version 3":   {Curve: curve, Transcript: TranscriptV1, Domain: domain(3, "P-256")},
+		"v3 SHA-512":     {Curve: curve, Hash: SHA512, Transcript: TranscriptV1, Domain: domain(3, "P-256_SHA-512"), HashToField: true},
+		"v2 other suite": {Curve: curve, Transcript: TranscriptV1, Domain: domain(2, "P-256_uncompressed")},
+		"v2 other name":  {Curve: curve, Transcript: TranscriptV1, Domain: &Domain{Protocol: "OWL2", Version: 2, Ciphersuite: "P-256"}},
+	}
+
+	seen := make(map[string]string)
+	record := func(name string, h *big.Int, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if other, ok := seen[h.String()]; ok {
+			t.Errorf("%s and %s hash to the same value", name, other)
+		}
+		seen[h.String()] = name
+	}
+
+	for name, suite := range suites {
+		h, err := suite.zkpChallenge(G, V, X, "alice")
+		record(name+" "+PurposeZKPChallenge, h, err)
+
+		// -- With a domain the purpose separates the same transcript
+		if suite.Domain == nil {
+			continue
+		}
+		for _, purpose := range []string{"t", "pi", "transcript", "session_key", "confirmation_key"} {
+			transcript := suite.NewTranscript(purpose)
+			transcript.Point("G", G.Encode())
+			transcript.Point("V", V)
+			transcript.Point("X", X)
+			transcript.String("prover", "alice")
+			h, err := transcript.SumScalar()
+			record(name+" "+purpose, h, err)
+		}
+	}
+}
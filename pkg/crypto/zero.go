@@ -0,0 +1,23 @@
+package crypto
+
+import "math/big"
+
+// ZeroBytes overwrites data with zeros.
+func ZeroBytes(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+}
+
+// ZeroBigInt overwrites the words backing x with zeros and sets x to 0.
+// Copies of x made by math/big (e.g. intermediate results) are not reached.
+func ZeroBigInt(x *big.Int) {
+	if x == nil {
+		return
+	}
+	words := x.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	x.SetInt64(0)
+}
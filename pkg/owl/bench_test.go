@@ -0,0 +1,89 @@
+package owl
+
+import (
+	"testing"
+
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+)
+
+// BenchmarkProtocol times each step of registration and login, and whole
+// handshakes, on every curve.
+func BenchmarkProtocol(b *testing.B) {
+	for _, id := range crypto.Curves {
+		curve, _ := id.Curve()
+		prefix := id.String() + "/"
+
+		b.Run(prefix+"ClientRegister", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				client, err := ClientInit("user", "password", "server", curve)
+				if err != nil {
+					b.Fatal(err)
+				}
+				client.Register()
+			}
+		})
+		b.Run(prefix+"ServerRegisterUser", func(b *testing.B) {
+			_, server, _ := register(b, curve, "user", "password")
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				server.RegisterUser()
+			}
+		})
+		b.Run(prefix+"ClientAuthInit", func(b *testing.B) {
+			client, _, _ := register(b, curve, "user", "password")
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				client.AuthInit()
+			}
+		})
+		b.Run(prefix+"ServerAuthInit", func(b *testing.B) {
+			client, server, serverRegistration := register(b, curve, "user", "password")
+			clientInit := client.AuthInit()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := server.AuthInit(serverRegistration, clientInit.Payload); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(prefix+"ClientAuthValidate", func(b *testing.B) {
+			client, server, serverRegistration := register(b, curve, "user", "password")
+			clientInit := client.AuthInit()
+			serverInit, err := server.AuthInit(serverRegistration, clientInit.Payload)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := client.AuthValidate(clientInit, serverInit.Payload); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(prefix+"ServerAuthValidate", func(b *testing.B) {
+			client, server, serverRegistration := register(b, curve, "user", "password")
+			clientInit := client.AuthInit()
+			serverInit, err := server.AuthInit(serverRegistration, clientInit.Payload)
+			if err != nil {
+				b.Fatal(err)
+			}
+			clientValidate, err := client.AuthValidate(clientInit, serverInit.Payload)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := server.AuthValidate(clientInit.Payload, clientValidate.Payload, serverInit); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(prefix+"Handshake", func(b *testing.B) {
+			client, server, serverRegistration := register(b, curve, "user", "password")
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				login(b, client, server, serverRegistration)
+			}
+		})
+	}
+}
@@ -1,15 +1,18 @@
 package owl
 
 import (
+	"context"
 	"crypto/elliptic"
 	"errors"
 	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
 	"math/big"
 )
 
+// Client runs the client side of the protocol. It is safe for concurrent
+// use (handshakes only read it) until Destroy is called. The per-handshake
+// objects (ClientAuthInitRequest, ClientAuthValidateRequest) are not.
 type Client struct {
 	UserIdentifier string
-	UserPassword   string
 	ServerName     string
 	Curve          elliptic.Curve
 	CurveParams    *elliptic.CurveParams
@@ -17,6 +20,11 @@ type Client struct {
 	t  *big.Int
 	PI *big.Int
 	T  []byte
+
+	// -- PI as it is sent, ScalarSize bytes
+	encodedPI crypto.Scalar
+
+	config config
 }
 
 func ClientInit(
@@ -24,146 +32,226 @@ func ClientInit(
 	pass string,
 	serverName string,
 	curve elliptic.Curve,
+	opts ...Option,
+) (*Client, error) {
+	// -- Go strings are immutable and cannot be wiped, prefer ClientInitBytes
+	return ClientInitBytes(user, []byte(pass), serverName, curve, opts...)
+}
+
+// ClientInitBytes is like ClientInit but takes the password as a byte slice,
+// which is wiped once t has been derived from it. The client never retains
+// the password itself.
+func ClientInitBytes(
+	user string,
+	pass []byte,
+	serverName string,
+	curve elliptic.Curve,
+	opts ...Option,
 ) (*Client, error) {
+	defer crypto.ZeroBytes(pass)
 
 	if user == serverName {
 		return nil, errors.New("user and server name cannot be the same")
 	}
 
+	cfg := newConfig(opts)
+	if !cfg.version.supported() {
+		return nil, ErrUnsupportedVersion
+	}
+
+	if !cfg.hash.Available() {
+		return nil, crypto.ErrUnsupportedHash
+	}
+
+	if !cfg.codec.Available() {
+		return nil, crypto.ErrUnsupportedCodec
+	}
+
+	if _, err := crypto.LookupCurve(curve); err != nil {
+		return nil, err
+	}
+
 	curveParams := curve.Params()
-	t := crypto.ModuloN(crypto.Hash(user, pass), curveParams.N)
-	π := crypto.ModuloN(crypto.Hash(t), curveParams.N)
-	T := crypto.MultiplyG(curve, t)
+	t, π := passwordVerifier(cfg.version.suite(curve, cfg.hash, cfg.codec), user, pass)
+	T := cfg.codec.Encode(crypto.MultiplyBase(curve, t))
+	encodedPI, err := crypto.NewScalar(curve, π)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Client{
 		UserIdentifier: user,
-		UserPassword:   pass,
 		ServerName:     serverName,
 		Curve:          curve,
 		t:              t,
 		PI:             π,
 		T:              T,
+		encodedPI:      encodedPI,
 		CurveParams:    curveParams,
+		config:         cfg,
 	}, nil
 }
 
+func (client *Client) ciphersuite() string {
+	return ciphersuiteName(client.Curve, client.config.hash)
+}
+
+func (client *Client) suite() *crypto.Suite {
+	return client.config.version.suite(client.Curve, client.config.hash, client.config.codec)
+}
+
 func (client *Client) Register() *RegistrationRequest {
+	// -- Copies, so that destroying the client does not wipe the request
 	payload := &RegistrationRequestPayload{
-		U:  client.UserIdentifier,
-		PI: client.PI,
-		T:  client.T,
+		U:       client.UserIdentifier,
+		PI:      append(crypto.Scalar(nil), client.encodedPI...),
+		T:       client.T,
+		Version: client.config.version,
+		Codec:   client.config.codec,
+		Hash:    client.config.hash,
 	}
 
 	return &RegistrationRequest{
 		Payload: payload,
-		t:       client.t,
+		t:       new(big.Int).Set(client.t),
 	}
 }
 
 func (client *Client) AuthInit() *ClientAuthInitRequest {
-	G := crypto.GetG(client.Curve)
+	// -- Cannot fail, the background context is never cancelled
+	clientInit, _ := client.AuthInitContext(context.Background())
+	return clientInit
+}
+
+// AuthInitContext is AuthInit with a context, it fails only if ctx is
+// already done.
+func (client *Client) AuthInitContext(ctx context.Context) (*ClientAuthInitRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	suite := client.suite()
+	G := crypto.BasePoint(client.Curve)
 	x1 := crypto.GenerateKey(client.Curve)
-	X1 := crypto.MultiplyG(client.Curve, x1)
-	PI1 := crypto.GenerateZKPGProvided(client.Curve, G, client.CurveParams.N, x1, X1, client.UserIdentifier)
+	pointX1 := crypto.MultiplyBase(client.Curve, x1)
+	X1 := suite.Codec.Encode(pointX1)
+	PI1 := suite.GenerateZKP(G, x1, X1, client.UserIdentifier)
 
 	x2 := crypto.GenerateKey(client.Curve)
-	X2 := crypto.MultiplyG(client.Curve, x2)
-	PI2 := crypto.GenerateZKPGProvided(client.Curve, G, client.CurveParams.N, x2, X2, client.UserIdentifier)
+	pointX2 := crypto.MultiplyBase(client.Curve, x2)
+	X2 := suite.Codec.Encode(pointX2)
+	PI2 := suite.GenerateZKP(G, x2, X2, client.UserIdentifier)
 
 	payload := &ClientAuthInitRequestPayload{
-		U:   client.UserIdentifier,
-		X1:  X1,
-		X2:  X2,
-		PI1: PI1,
-		PI2: PI2,
+		U:       client.UserIdentifier,
+		S:       client.ServerName,
+		X1:      X1,
+		X2:      X2,
+		PI1:     PI1,
+		PI2:     PI2,
+		Version: client.config.version,
+		Codec:   client.config.codec,
+		Hash:    client.config.hash,
 	}
 
 	return &ClientAuthInitRequest{
 		Payload: payload,
 		x1:      x1,
 		x2:      x2,
-	}
+		pointX1: pointX1,
+		pointX2: pointX2,
+	}, nil
 }
 
 func (client *Client) AuthValidate(
 	clientInit *ClientAuthInitRequest,
 	serverInit *ServerAuthInitResponsePayload,
 ) (*ClientAuthValidateRequest, error) {
+	return client.AuthValidateContext(context.Background(), clientInit, serverInit)
+}
 
-	curve := client.Curve
-	G := crypto.GetG(client.Curve)
-
-	if !crypto.VerifyZKP(curve, G, serverInit.X3, *serverInit.PI3, client.ServerName) {
-		return nil, errors.New("ZKP Verification Failed for PI3")
-	}
-
-	if !crypto.VerifyZKP(curve, G, serverInit.X4, *serverInit.PI4, client.ServerName) {
-		return nil, errors.New("ZKP Verification Failed for PI4")
-	}
-
-	X1X2, err := crypto.AddPoints(curve, clientInit.Payload.X1, clientInit.Payload.X2)
-	if err != nil {
+// AuthValidateContext is AuthValidate with a context, which is checked
+// before and after the ZKP verifications.
+func (client *Client) AuthValidateContext(
+	ctx context.Context,
+	clientInit *ClientAuthInitRequest,
+	serverInit *ServerAuthInitResponsePayload,
+) (*ClientAuthValidateRequest, error) {
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	GBeta, err := crypto.AddPoints(curve, X1X2, serverInit.X3)
-	if err != nil {
+	if err := serverInit.validate(); err != nil {
 		return nil, err
 	}
-	if !crypto.VerifyZKP(curve, GBeta, serverInit.Beta, *serverInit.PIBeta, client.ServerName) {
-		return nil, errors.New("ZKP Verification Failed for PIBeta")
-	}
 
-	X1X3, err := crypto.AddPoints(curve, clientInit.Payload.X1, serverInit.X3)
-	if err != nil {
-		return nil, err
-	}
+	curve := client.Curve
+	suite := client.suite()
+	G := crypto.BasePoint(curve)
+	X1, X2 := clientInit.pointX1, clientInit.pointX2
 
-	Gα, err := crypto.AddPoints(curve, X1X3, serverInit.X4)
+	// -- Decoded once, a point that does not decode fails its proof
+	X3, err := suite.Codec.Decode(curve, serverInit.X3)
 	if err != nil {
-		return nil, err
+		return nil, ErrPI3Verification
 	}
 
-	x2π := crypto.ModuloN(crypto.Multiply(clientInit.x2, client.PI), client.CurveParams.N)
-	α, err := crypto.MultiplyPoint(curve, &Gα, x2π)
+	X4, err := suite.Codec.Decode(curve, serverInit.X4)
 	if err != nil {
-		return nil, err
+		return nil, ErrPI4Verification
 	}
-	PIAlpha := crypto.GenerateZKPGProvided(curve, Gα, client.CurveParams.N, x2π, α, client.UserIdentifier)
 
-	X4x2π, err := crypto.MultiplyPoint(curve, &serverInit.X4, x2π)
+	β, err := suite.Codec.Decode(curve, serverInit.Beta)
 	if err != nil {
-		return nil, err
+		return nil, ErrPIBetaVerification
 	}
-	rawClientKey, err := crypto.SubtractPoints(curve, serverInit.Beta, X4x2π)
+
+	GBeta := X1.Add(X2).Add(X3)
+
+	// -- PI3, PI4 and PIBeta are independent, so they are verified together
+	err = client.config.verifyZKPs(client.ciphersuite(), suite,
+		zkpCheck{crypto.ZKPStatement{Generator: G, X: X3, ZKP: *serverInit.PI3, Prover: client.ServerName}, OperationVerifyPI3, ErrPI3Verification},
+		zkpCheck{crypto.ZKPStatement{Generator: G, X: X4, ZKP: *serverInit.PI4, Prover: client.ServerName}, OperationVerifyPI4, ErrPI4Verification},
+		zkpCheck{crypto.ZKPStatement{Generator: GBeta, X: β, ZKP: *serverInit.PIBeta, Prover: client.ServerName}, OperationVerifyPIBeta, ErrPIBetaVerification},
+	)
 	if err != nil {
 		return nil, err
 	}
-	rawClientKey, err = crypto.MultiplyPoint(curve, &rawClientKey, clientInit.x2)
-	if err != nil {
+
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	clientSessionKey := crypto.Hash(rawClientKey, SessionKey)
-	clientKCKey := crypto.Hash(rawClientKey, ConfirmationKey)
+	Gα := X1.Add(X3).Add(X4)
+
+	x2π := crypto.ModuloN(crypto.Multiply(clientInit.x2, client.PI), client.CurveParams.N)
+	α := suite.Codec.Encode(Gα.Multiply(x2π))
+	PIAlpha := suite.GenerateZKP(Gα, x2π, α, client.UserIdentifier)
 
-	hTranscript := crypto.Hash(
+	rawClientKey := suite.Codec.Encode(β.Subtract(X4.Multiply(x2π)).Multiply(clientInit.x2))
+
+	clientSessionKey := deriveKey(suite, rawClientKey, SessionKey)
+	clientKCKey := deriveKey(suite, rawClientKey, ConfirmationKey)
+
+	hTranscript, err := transcriptHash(
+		suite,
 		rawClientKey,
-		client.UserIdentifier,
-		clientInit.Payload.X1, clientInit.Payload.X2,
-		clientInit.Payload.PI1, clientInit.Payload.PI2,
-		client.ServerName,
-		serverInit.X3, serverInit.X4,
-		*serverInit.PI3, *serverInit.PI4,
-		serverInit.Beta, *serverInit.PIBeta,
+		client.UserIdentifier, clientInit.Payload,
+		client.ServerName, serverInit,
 		α, PIAlpha,
 	)
+	if err != nil {
+		return nil, err
+	}
 
-	hTranscript = crypto.ModuloN(hTranscript, client.CurveParams.N)
 	rValue := crypto.Subtract(clientInit.x1, crypto.Multiply(client.t, hTranscript))
 	rValue = crypto.ModuloN(rValue, client.CurveParams.N)
+	R, err := crypto.NewScalar(client.Curve, rValue)
+	if err != nil {
+		return nil, err
+	}
 
-	clientKCTag := crypto.DeriveHMACTag(
+	clientKCTag := suite.DeriveHMACTag(
 		clientKCKey,
 		ClientKCKeyTag,
 		client.UserIdentifier,
@@ -176,16 +264,25 @@ func (client *Client) AuthValidate(
 		ClientKCTag: clientKCTag,
 		Alpha:       α,
 		PIAlpha:     PIAlpha,
-		R:           rValue,
+		R:           R,
 	}
 
-	return &ClientAuthValidateRequest{
-		Payload:          payload,
-		RawClientKey:     rawClientKey,
-		ClientSessionKey: clientSessionKey,
-		ClientKCKey:      clientKCKey,
-		HTranscript:      hTranscript,
-	}, nil
+	clientValidate := &ClientAuthValidateRequest{
+		Payload:      payload,
+		HTranscript:  hTranscript,
+		init:         clientInit,
+		rawClientKey: rawClientKey,
+		kcKey:        clientKCKey,
+		sessionKey:   clientSessionKey,
+		strict:       client.config.strictKeyConfirmation,
+	}
+
+	// -- In strict mode the key is only handed out by VerifyResponse
+	if !clientValidate.strict {
+		clientValidate.ClientSessionKey = clientSessionKey
+	}
+
+	return clientValidate, nil
 }
 
 func (client *Client) VerifyResponse(
@@ -194,9 +291,32 @@ func (client *Client) VerifyResponse(
 	serverInit *ServerAuthInitResponsePayload,
 	serverValidate *ServerAuthValidateResponsePayload,
 ) error {
+	return client.VerifyResponseContext(context.Background(), clientInit, clientValidate, serverInit, serverValidate)
+}
 
-	serverKCTag2 := crypto.DeriveHMACTag(
-		clientValidate.ClientKCKey,
+// VerifyResponseContext is VerifyResponse with a context, the session key is
+// not released if ctx is done.
+func (client *Client) VerifyResponseContext(
+	ctx context.Context,
+	clientInit *ClientAuthInitRequest,
+	clientValidate *ClientAuthValidateRequest,
+	serverInit *ServerAuthInitResponsePayload,
+	serverValidate *ServerAuthValidateResponsePayload,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := errors.Join(serverInit.validate(), serverValidate.validate()); err != nil {
+		return err
+	}
+
+	if clientValidate.init != clientInit {
+		return ErrHandshakeMismatch
+	}
+
+	serverKCTag2 := client.suite().DeriveHMACTag(
+		clientValidate.kcKey,
 		ServerKCKeyTag,
 		client.ServerName,
 		client.UserIdentifier,
@@ -204,9 +324,114 @@ func (client *Client) VerifyResponse(
 		clientInit.Payload.X1, clientInit.Payload.X2,
 	)
 
-	if serverKCTag2.Cmp(serverValidate.ServerKCTag) != 0 {
-		return errors.New("ERROR: invalid r (client authentication failed)")
+	if !client.config.version.kcTagsEqual(serverKCTag2, serverValidate.ServerKCTag) {
+		return ErrServerKCTagMismatch
 	}
 
+	clientValidate.confirmed = true
+	clientValidate.ClientSessionKey = clientValidate.sessionKey
 	return nil
 }
+
+// ChangePassword builds a request replacing the users password with newPass.
+// It can only be called once the login has been confirmed (VerifyResponse
+// succeeded), so the new verifier is never handed to an unauthenticated
+// server. The request is MACed with a key derived from that session, newPass
+// is wiped.
+func (client *Client) ChangePassword(
+	clientValidate *ClientAuthValidateRequest,
+	newPass []byte,
+) (*PasswordChangeRequest, error) {
+	defer crypto.ZeroBytes(newPass)
+
+	if !clientValidate.confirmed {
+		return nil, ErrKeyNotConfirmed
+	}
+
+	newClient, err := ClientInitBytes(client.UserIdentifier, newPass, client.ServerName, client.Curve, withConfig(client.config))
+	if err != nil {
+		return nil, err
+	}
+
+	tag := credentialUpdateTag(
+		client.suite(),
+		clientValidate.rawClientKey,
+		PasswordChangeKeyTag,
+		newClient.UserIdentifier,
+		newClient.ServerName,
+		newClient.encodedPI,
+		newClient.T,
+	)
+
+	payload := &PasswordChangeRequestPayload{
+		U:   newClient.UserIdentifier,
+		PI:  append(crypto.Scalar(nil), newClient.encodedPI...),
+		T:   newClient.T,
+		Tag: tag,
+	}
+
+	return &PasswordChangeRequest{
+		Payload: payload,
+		Client:  newClient,
+	}, nil
+}
+
+// ChangeUsername builds a request that moves the users registration to
+// newUser. As t = H(user, pass), the password is needed to re-register under
+// the new name, it is wiped. Like ChangePassword it requires a confirmed
+// login and the request is MACed with a key derived from that session.
+func (client *Client) ChangeUsername(
+	clientValidate *ClientAuthValidateRequest,
+	newUser string,
+	pass []byte,
+) (*UsernameChangeRequest, error) {
+	defer crypto.ZeroBytes(pass)
+
+	if !clientValidate.confirmed {
+		return nil, ErrKeyNotConfirmed
+	}
+
+	// -- Catch a mistyped password before re-registering with it
+	currentT, currentπ := passwordVerifier(client.suite(), client.UserIdentifier, pass)
+	defer crypto.ZeroBigInt(currentT)
+	defer crypto.ZeroBigInt(currentπ)
+	if currentT.Cmp(client.t) != 0 {
+		return nil, errors.New("password does not match the current password")
+	}
+
+	newClient, err := ClientInitBytes(newUser, pass, client.ServerName, client.Curve, withConfig(client.config))
+	if err != nil {
+		return nil, err
+	}
+
+	tag := credentialUpdateTag(
+		client.suite(),
+		clientValidate.rawClientKey,
+		UsernameChangeKeyTag,
+		newClient.UserIdentifier,
+		newClient.ServerName,
+		newClient.encodedPI,
+		newClient.T,
+	)
+
+	payload := &UsernameChangeRequestPayload{
+		U:    client.UserIdentifier,
+		NewU: newClient.UserIdentifier,
+		PI:   append(crypto.Scalar(nil), newClient.encodedPI...),
+		T:    newClient.T,
+		Tag:  tag,
+	}
+
+	return &UsernameChangeRequest{
+		Payload: payload,
+		Client:  newClient,
+	}, nil
+}
+
+// Destroy wipes the secrets held by the client (t and π), the client must
+// not be used afterwards.
+func (client *Client) Destroy() {
+	crypto.ZeroBigInt(client.t)
+	crypto.ZeroBigInt(client.PI)
+	crypto.ZeroBytes(client.encodedPI)
+}
@@ -0,0 +1,67 @@
+package owl
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"testing"
+
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+)
+
+func TestLoginInEveryCodec(t *testing.T) {
+	for _, id := range crypto.Curves {
+		curve, _ := id.Curve()
+		for _, codec := range crypto.PointCodecs {
+			t.Run(id.String()+"/"+codec.String(), func(t *testing.T) {
+				client, server, serverRegistration := register(t, curve, "alice", "password", WithVersion(LatestVersion), WithPointCodec(codec))
+				if server.UserRegistration.Codec != codec {
+					t.Fatalf("record registered with %s", server.UserRegistration.Codec)
+				}
+				clientValidate, serverValidate := login(t, client, server, serverRegistration)
+				if clientValidate.ClientSessionKey.Cmp(serverValidate.ServerSessionKey) != 0 {
+					t.Fatal("session keys differ")
+				}
+				if len(clientValidate.Payload.Alpha) != codec.Size(curve) {
+					t.Errorf("alpha of %d bytes, want %d", len(clientValidate.Payload.Alpha), codec.Size(curve))
+				}
+			})
+		}
+	}
+}
+
+func TestCodecIsFixedAtRegistration(t *testing.T) {
+	curve := elliptic.P256()
+	client, server, serverRegistration := register(t, curve, "alice", "password", WithVersion(LatestVersion))
+
+	// -- A client using another encoding than its record is refused before
+	// any point is decoded
+	other, err := ClientInit("alice", "password", "server", curve, WithVersion(LatestVersion), WithPointCodec(crypto.CodecRaw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = server.AuthInit(serverRegistration, other.AuthInit().Payload)
+	if !errors.Is(err, ErrCodecMismatch) || ClassifyFailure(err) != FailureCodec {
+		t.Fatalf("AuthInit with another codec: got %v", err)
+	}
+
+	clientInit := client.AuthInit()
+	serverInit, err := server.AuthInit(serverRegistration, clientInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientValidate, err := client.AuthValidate(clientInit, serverInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := *clientInit.Payload
+	payload.Codec = crypto.CodecRaw
+	if _, err := server.AuthValidate(&payload, clientValidate.Payload, serverInit); !errors.Is(err, ErrCodecMismatch) {
+		t.Fatalf("AuthValidate with another codec: got %v", err)
+	}
+
+	registration := client.Register().Payload
+	registration.Codec = crypto.CodecRaw + 1
+	if _, err := ServerInit("server", curve, registration); !errors.Is(err, crypto.ErrUnsupportedCodec) {
+		t.Fatalf("unsupported codec: got %v", err)
+	}
+}
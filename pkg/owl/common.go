@@ -1,11 +1,155 @@
 package owl
 
+import (
+	"errors"
+	"fmt"
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+	"math/big"
+)
+
+const (
+	SessionKey        = "session_key"
+	ConfirmationKey   = "confirmation_key"
+	PasswordChangeKey = "password_change_key"
+)
+
+// -- Purposes of the other hashes, written from Version2 on
 const (
-	SessionKey      = "session_key"
-	ConfirmationKey = "confirmation_key"
+	purposeT          = "t"
+	purposePI         = "pi"
+	purposeTranscript = "transcript"
 )
 
 const (
-	ClientKCKeyTag = "KC_1_U"
-	ServerKCKeyTag = "KC_1_V"
+	ClientKCKeyTag       = "KC_1_U"
+	ServerKCKeyTag       = "KC_1_V"
+	PasswordChangeKeyTag = "PWD_CHANGE_U"
+	UsernameChangeKeyTag = "USER_CHANGE_U"
 )
+
+var (
+	ErrKeyNotConfirmed   = errors.New("session key withheld, key confirmation has not completed")
+	ErrHandshakeMismatch = errors.New("handshake messages do not belong to the same session")
+	ErrRecordChanged     = errors.New("stored registration changed since the login, retry")
+	ErrUnknownUser       = errors.New("no registration stored for this user")
+	ErrUserExists        = errors.New("a registration is already stored for this user")
+)
+
+var (
+	ErrPI1Verification     = errors.New("ZKP Verification Failed for PI1")
+	ErrPI2Verification     = errors.New("ZKP Verification Failed for PI2")
+	ErrPI3Verification     = errors.New("ZKP Verification Failed for PI3")
+	ErrPI4Verification     = errors.New("ZKP Verification Failed for PI4")
+	ErrPIBetaVerification  = errors.New("ZKP Verification Failed for PIBeta")
+	ErrPIAlphaVerification = errors.New("ZKP Verification Failed for PIAlpha")
+	ErrClientKCTagMismatch = errors.New("client authentication failed, ClientKCTag mismatch")
+	ErrServerKCTagMismatch = errors.New("ERROR: invalid r (client authentication failed)")
+	ErrX1Mismatch          = errors.New("client authentication failed, X1 mismatch")
+	ErrUnknownServerName   = errors.New("unknown server name")
+)
+
+// credentialUpdateTag binds a new verifier (PI, T) to an authenticated
+// session, the MAC key is derived from the raw session key.
+func credentialUpdateTag(
+	suite *crypto.Suite,
+	rawKey []byte,
+	messageString string,
+	user string,
+	server string,
+	PI crypto.Scalar,
+	T []byte,
+) []byte {
+	updateKey := deriveKey(suite, rawKey, PasswordChangeKey)
+	defer crypto.ZeroBigInt(updateKey)
+
+	return crypto.DeriveMACWith(
+		suite.Hash,
+		updateKey,
+		[]byte(messageString),
+		[]byte(user),
+		[]byte(server),
+		PI,
+		T,
+	)
+}
+
+// transcriptHash hashes everything both sides have seen in a login, reduced
+// modulo N. The client proves knowledge of t over it (r = x1 - t·h).
+func transcriptHash(
+	suite *crypto.Suite,
+	rawKey []byte,
+	user string,
+	clientInit *ClientAuthInitRequestPayload,
+	server string,
+	serverInit *ServerAuthInitResponsePayload,
+	α []byte,
+	PIAlpha *crypto.SchnorrZKP,
+) (*big.Int, error) {
+	transcript := suite.NewTranscript(purposeTranscript)
+	transcript.Bytes("K", rawKey)
+	transcript.String("U", user)
+	transcript.Point("X1", clientInit.X1)
+	transcript.Point("X2", clientInit.X2)
+	transcript.ZKP("PI1", clientInit.PI1)
+	transcript.ZKP("PI2", clientInit.PI2)
+	transcript.String("S", server)
+	transcript.Point("X3", serverInit.X3)
+	transcript.Point("X4", serverInit.X4)
+	transcript.ZKP("PI3", serverInit.PI3)
+	transcript.ZKP("PI4", serverInit.PI4)
+	transcript.Point("Beta", serverInit.Beta)
+	transcript.ZKP("PIBeta", serverInit.PIBeta)
+	transcript.Point("Alpha", α)
+	transcript.ZKP("PIAlpha", PIAlpha)
+
+	h, err := transcript.SumScalar()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedMessage, err)
+	}
+	return h, nil
+}
+
+// passwordVerifier derives t = H(user, pass) and π = H(t), both modulo N.
+func passwordVerifier(suite *crypto.Suite, user string, pass []byte) (*big.Int, *big.Int) {
+	transcript := suite.NewTranscript(purposeT)
+	transcript.String("U", user)
+	transcript.Bytes("password", pass)
+	t := mustSumScalar(transcript)
+
+	transcript = suite.NewTranscript(purposePI)
+	transcript.Scalar("t", t)
+	π := mustSumScalar(transcript)
+
+	return t, π
+}
+
+// deriveKey derives the key for purpose (SessionKey, ConfirmationKey, ...)
+// from the raw key of a login. Without a domain the purpose is hashed after
+// the key, as the legacy protocol does.
+func deriveKey(suite *crypto.Suite, rawKey []byte, purpose string) *big.Int {
+	transcript := suite.NewTranscript(purpose)
+	transcript.Bytes("K", rawKey)
+	if suite.Domain == nil {
+		transcript.String("purpose", purpose)
+	}
+	return mustSum(transcript)
+}
+
+// mustSum is Sum for transcripts of locally computed values, which always
+// have an encoding.
+func mustSum(transcript *crypto.Transcript) *big.Int {
+	h, err := transcript.Sum()
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// mustSumScalar is SumScalar for transcripts of locally computed values.
+func mustSumScalar(transcript *crypto.Transcript) *big.Int {
+	h, err := transcript.SumScalar()
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
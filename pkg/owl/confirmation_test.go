@@ -0,0 +1,92 @@
+package owl
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestStrictKeyConfirmation(t *testing.T) {
+	client, server, serverRegistration := register(t, elliptic.P256(), "alice", "password", WithStrictKeyConfirmation())
+
+	clientInit := client.AuthInit()
+	serverInit, err := server.AuthInit(serverRegistration, clientInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientValidate, err := client.AuthValidate(clientInit, serverInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := clientValidate.SessionKey(); !errors.Is(err, ErrKeyNotConfirmed) || key != nil || clientValidate.ClientSessionKey != nil {
+		t.Fatalf("client key released before VerifyResponse: %v", err)
+	}
+
+	serverValidate, err := server.AuthValidate(clientInit.Payload, clientValidate.Payload, serverInit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := serverValidate.SessionKey(); !errors.Is(err, ErrKeyNotConfirmed) || key != nil || serverValidate.ServerSessionKey != nil {
+		t.Fatalf("server key released before ConfirmationSent: %v", err)
+	}
+
+	// -- A wrong server KC tag keeps the client key withheld
+	forged := *serverValidate.Payload
+	forged.ServerKCTag = append([]byte(nil), forged.ServerKCTag...)
+	forged.ServerKCTag[0] ^= 1
+	if err := client.VerifyResponse(clientInit, clientValidate, serverInit.Payload, &forged); !errors.Is(err, ErrServerKCTagMismatch) {
+		t.Fatalf("forged ServerKCTag: got %v", err)
+	}
+	if _, err := clientValidate.SessionKey(); !errors.Is(err, ErrKeyNotConfirmed) || clientValidate.ClientSessionKey != nil {
+		t.Fatal("client key released by a forged ServerKCTag")
+	}
+
+	if err := client.VerifyResponse(clientInit, clientValidate, serverInit.Payload, serverValidate.Payload); err != nil {
+		t.Fatal(err)
+	}
+	clientKey, err := clientValidate.SessionKey()
+	if err != nil || clientKey == nil || clientKey.Cmp(clientValidate.ClientSessionKey) != 0 {
+		t.Fatalf("client key not released after VerifyResponse: %v", err)
+	}
+
+	// -- The client is confirmed, the server still waits for the send
+	if _, err := serverValidate.SessionKey(); !errors.Is(err, ErrKeyNotConfirmed) {
+		t.Fatalf("server key released before ConfirmationSent: %v", err)
+	}
+	serverValidate.ConfirmationSent()
+	serverKey, err := serverValidate.SessionKey()
+	if err != nil || serverKey.Cmp(clientKey) != 0 || serverValidate.ServerSessionKey.Cmp(clientKey) != 0 {
+		t.Fatalf("server key not released after ConfirmationSent: %v", err)
+	}
+}
+
+func TestKeysWithoutStrictKeyConfirmation(t *testing.T) {
+	client, server, serverRegistration := register(t, elliptic.P256(), "alice", "password")
+
+	clientInit := client.AuthInit()
+	serverInit, err := server.AuthInit(serverRegistration, clientInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientValidate, err := client.AuthValidate(clientInit, serverInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverValidate, err := server.AuthValidate(clientInit.Payload, clientValidate.Payload, serverInit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, key := range map[string]func() (*big.Int, error){
+		"client": clientValidate.SessionKey,
+		"server": serverValidate.SessionKey,
+	} {
+		if key, err := key(); err != nil || key == nil {
+			t.Errorf("%s key withheld: %v", name, err)
+		}
+	}
+	if clientValidate.ClientSessionKey.Cmp(serverValidate.ServerSessionKey) != 0 {
+		t.Error("session keys differ")
+	}
+}
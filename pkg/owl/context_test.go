@@ -0,0 +1,58 @@
+package owl
+
+import (
+	"context"
+	"crypto/elliptic"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCancelledContextAbortsLogin(t *testing.T) {
+	curve := elliptic.P256()
+	store := NewMemoryCounterStore()
+	_, server, serverRegistration := register(t, curve, "alice", "password", WithThrottler(NewThrottler(store)))
+	guesser, _ := ClientInit("alice", "guess", "server", curve)
+
+	background := ContextWithClientAddress(context.Background(), "192.0.2.1")
+	cancelled, cancel := context.WithCancel(background)
+	cancel()
+	expired, cancelExpired := context.WithDeadline(background, time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	if _, err := guesser.AuthInitContext(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("client AuthInitContext: got %v, want context.Canceled", err)
+	}
+
+	clientInit := guesser.AuthInit()
+	if _, err := server.AuthInitContext(cancelled, serverRegistration, clientInit.Payload); !errors.Is(err, context.Canceled) {
+		t.Errorf("AuthInitContext: got %v, want context.Canceled", err)
+	}
+
+	serverInit, err := server.AuthInitContext(background, serverRegistration, clientInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientValidate, err := guesser.AuthValidate(clientInit, serverInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ctx, want := range map[context.Context]error{cancelled: context.Canceled, expired: context.DeadlineExceeded} {
+		if _, err := server.AuthValidateContext(ctx, clientInit.Payload, clientValidate.Payload, serverInit); !errors.Is(err, want) {
+			t.Errorf("AuthValidateContext: got %v, want %v", err, want)
+		}
+	}
+
+	// -- The aborted guesses are not counted, the completed one is
+	for _, key := range []string{userKey("alice"), addressKey("192.0.2.1")} {
+		if failures, _, _ := store.Get(context.Background(), key); failures != 0 {
+			t.Errorf("%s has %d failures after aborted logins", key, failures)
+		}
+	}
+	if _, err := server.AuthValidateContext(background, clientInit.Payload, clientValidate.Payload, serverInit); !errors.Is(err, ErrClientKCTagMismatch) {
+		t.Fatalf("AuthValidateContext: got %v, want ErrClientKCTagMismatch", err)
+	}
+	if failures, _, _ := store.Get(context.Background(), userKey("alice")); failures != 1 {
+		t.Errorf("%d failures after a completed guess, want 1", failures)
+	}
+}
@@ -0,0 +1,203 @@
+package owl
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+)
+
+// register registers user on a new Server, the server side of a record.
+func register(t testing.TB, curve elliptic.Curve, user string, pass string, opts ...Option) (*Client, *Server, *RegistrationResponse) {
+	t.Helper()
+	client, err := ClientInit(user, pass, "server", curve, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ServerInit("server", curve, client.Register().Payload, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, server, server.RegisterUser()
+}
+
+// login runs a confirmed login of client against server.
+func login(t testing.TB, client *Client, server *Server, serverRegistration *RegistrationResponse) (*ClientAuthValidateRequest, *ServerAuthValidateResponse) {
+	t.Helper()
+	clientInit := client.AuthInit()
+	serverInit, err := server.AuthInit(serverRegistration, clientInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientValidate, err := client.AuthValidate(clientInit, serverInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverValidate, err := server.AuthValidate(clientInit.Payload, clientValidate.Payload, serverInit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.VerifyResponse(clientInit, clientValidate, serverInit.Payload, serverValidate.Payload); err != nil {
+		t.Fatal(err)
+	}
+	return clientValidate, serverValidate
+}
+
+// forgeUpdate MACs a new verifier for victim with the key of a login of
+// another user, what that user can always compute.
+func forgeUpdate(clientValidate *ClientAuthValidateRequest, client *Client, messageString string, victim string) (crypto.Scalar, []byte, []byte) {
+	attacker, _ := ClientInit(victim, "attacker password", "server", client.Curve, withConfig(client.config))
+	tag := credentialUpdateTag(client.suite(), clientValidate.rawClientKey, messageString, victim, "server", attacker.encodedPI, attacker.T)
+	return attacker.encodedPI, attacker.T, tag
+}
+
+func TestChangePasswordRejectsLoginOfAnotherUser(t *testing.T) {
+	curve := elliptic.P256()
+	alice, aliceServer, aliceRegistration := register(t, curve, "alice", "alice password")
+	bob, bobServer, bobRegistration := register(t, curve, "bob", "bob password")
+	store := NewMemoryCredentialStore()
+	store.Store(bobServer.Registration())
+
+	clientValidate, serverValidate := login(t, alice, aliceServer, aliceRegistration)
+	PI, T, tag := forgeUpdate(clientValidate, alice, PasswordChangeKeyTag, "bob")
+	request := &PasswordChangeRequestPayload{U: "bob", PI: PI, T: T, Tag: tag}
+	if _, err := bobServer.ChangePassword(store, serverValidate, request); err == nil {
+		t.Fatal("a login of alice changed the password of bob")
+	}
+
+	login(t, bob, bobServer, bobRegistration)
+}
+
+func TestChangeUsernameRejectsLoginOfAnotherUser(t *testing.T) {
+	curve := elliptic.P256()
+	alice, aliceServer, aliceRegistration := register(t, curve, "alice", "alice password")
+	_, bobServer, _ := register(t, curve, "bob", "bob password")
+	store := NewMemoryCredentialStore()
+	store.Store(bobServer.Registration())
+
+	clientValidate, serverValidate := login(t, alice, aliceServer, aliceRegistration)
+	PI, T, tag := forgeUpdate(clientValidate, alice, UsernameChangeKeyTag, "mallory")
+	request := &UsernameChangeRequestPayload{U: "bob", NewU: "mallory", PI: PI, T: T, Tag: tag}
+	if _, err := bobServer.ChangeUsername(store, serverValidate, request); err == nil {
+		t.Fatal("a login of alice renamed bob")
+	}
+	if _, err := store.Load("bob"); err != nil {
+		t.Fatal("the record of bob was moved")
+	}
+}
+
+func TestCredentialUpdatesRequireLogin(t *testing.T) {
+	curve := elliptic.P256()
+	client, server, serverRegistration := register(t, curve, "alice", "password")
+	store := NewMemoryCredentialStore()
+	store.Store(server.Registration())
+
+	clientValidate, _ := login(t, client, server, serverRegistration)
+	passwordChange, err := client.ChangePassword(clientValidate, []byte("new password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	usernameChange, err := client.ChangeUsername(clientValidate, "bob", []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := server.ChangePassword(store, nil, passwordChange.Payload); err == nil {
+		t.Error("password changed without a login")
+	}
+	if _, err := server.ChangeUsername(store, nil, usernameChange.Payload); err == nil {
+		t.Error("username changed without a login")
+	}
+	if _, err := store.Load("alice"); err != nil {
+		t.Fatal("the record of alice was moved")
+	}
+}
+
+func TestMigrateServerName(t *testing.T) {
+	curve := elliptic.P256()
+	client, server, serverRegistration := register(t, curve, "alice", "password")
+
+	server.ServerName = "new server"
+	migrated, err := server.MigrateServerName(serverRegistration, "server", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// -- Clients under either name log in during the grace period
+	login(t, client, server, migrated)
+	renamed, err := ClientInit("alice", "password", "new server", curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	login(t, renamed, server, migrated)
+
+	// -- After it only the new name is accepted
+	migrated.Legacy.Until = time.Now().Add(-time.Second)
+	if _, err := server.AuthInit(migrated, client.AuthInit().Payload); !errors.Is(err, ErrUnknownServerName) {
+		t.Errorf("login under the old name after the grace period: got %v", err)
+	}
+	login(t, renamed, server, migrated)
+}
+
+func TestChangePasswordRejectsStaleRecord(t *testing.T) {
+	curve := elliptic.P256()
+	client, server, serverRegistration := register(t, curve, "alice", "password")
+	store := NewMemoryCredentialStore()
+	store.Store(server.Registration())
+
+	first, firstServer := login(t, client, server, serverRegistration)
+	second, secondServer := login(t, client, server, serverRegistration)
+
+	change, err := client.ChangePassword(first, []byte("new password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.ChangePassword(store, firstServer, change.Payload); err != nil {
+		t.Fatal(err)
+	}
+
+	// -- The second login was made against the record that was replaced
+	change, err = client.ChangePassword(second, []byte("other password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.ChangePassword(store, secondServer, change.Payload); err != ErrRecordChanged {
+		t.Fatalf("got %v, want ErrRecordChanged", err)
+	}
+}
+
+func TestChangePasswordRejectsSmallOrderT(t *testing.T) {
+	curve := crypto.Edwards25519()
+	opts := []Option{WithVersion(LatestVersion)}
+	client, server, serverRegistration := register(t, curve, "alice", "password", opts...)
+	store := NewMemoryCredentialStore()
+	store.Store(server.Registration())
+
+	order8, _ := hex.DecodeString("26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc05")
+	identity, _ := hex.DecodeString("0100000000000000000000000000000000000000000000000000000000000000")
+	L, err := crypto.DecodePoint(curve, order8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, T := range map[string]func(*Client) []byte{
+		"identity":  func(*Client) []byte { return identity },
+		"order 8":   func(*Client) []byte { return order8 },
+		"torsioned": func(c *Client) []byte { T, _ := crypto.DecodePoint(curve, c.T); return T.Add(L).Encode() },
+	} {
+		clientValidate, serverValidate := login(t, client, server, serverRegistration)
+		change, err := client.ChangePassword(clientValidate, []byte("new password"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		change.Payload.T = T(change.Client)
+		change.Payload.Tag = credentialUpdateTag(client.suite(), clientValidate.rawClientKey, PasswordChangeKeyTag,
+			"alice", "server", change.Payload.PI, change.Payload.T)
+		if _, err := server.ChangePassword(store, serverValidate, change.Payload); err == nil {
+			t.Errorf("%s T was stored", name)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package owl
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+)
+
+// secret records a secret before Destroy, wiped reports whether its value
+// and the memory backing it are zero afterwards.
+type secret struct {
+	name  string
+	value *big.Int
+	raw   []byte
+	words []big.Word
+}
+
+func bigSecret(name string, value *big.Int) secret {
+	return secret{name: name, value: value, words: value.Bits()}
+}
+
+func (s secret) wiped() bool {
+	for _, word := range s.words {
+		if word != 0 {
+			return false
+		}
+	}
+	for _, b := range s.raw {
+		if b != 0 {
+			return false
+		}
+	}
+	return s.value == nil || s.value.Sign() == 0
+}
+
+func checkDestroyed(t *testing.T, destroy func(), secrets ...secret) {
+	t.Helper()
+	for _, s := range secrets {
+		if s.wiped() {
+			t.Fatalf("%s is zero before Destroy", s.name)
+		}
+	}
+	destroy()
+	for _, s := range secrets {
+		if !s.wiped() {
+			t.Errorf("%s not wiped by Destroy", s.name)
+		}
+	}
+}
+
+func TestDestroyWipesSecrets(t *testing.T) {
+	client, server, serverRegistration := register(t, elliptic.P256(), "alice", "password")
+
+	registration := client.Register()
+	checkDestroyed(t, registration.Destroy, bigSecret("t", registration.t))
+
+	clientInit := client.AuthInit()
+	serverInit, err := server.AuthInit(serverRegistration, clientInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientValidate, err := client.AuthValidate(clientInit, serverInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverValidate, err := server.AuthValidate(clientInit.Payload, clientValidate.Payload, serverInit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkDestroyed(t, clientInit.Destroy, bigSecret("x1", clientInit.x1), bigSecret("x2", clientInit.x2))
+	checkDestroyed(t, serverInit.Destroy, bigSecret("x4", serverInit.Xx4))
+	checkDestroyed(t, clientValidate.Destroy,
+		secret{name: "client raw key", raw: clientValidate.rawClientKey},
+		bigSecret("client KC key", clientValidate.kcKey),
+		bigSecret("client session key", clientValidate.sessionKey),
+	)
+	checkDestroyed(t, serverValidate.Destroy,
+		secret{name: "server raw key", raw: serverValidate.rawServerKey},
+		bigSecret("server KC key", serverValidate.kcKey),
+		bigSecret("server session key", serverValidate.sessionKey),
+	)
+
+	if clientValidate.ClientSessionKey != nil || serverValidate.ServerSessionKey != nil {
+		t.Error("session key still exported after Destroy")
+	}
+}
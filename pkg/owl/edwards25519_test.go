@@ -0,0 +1,48 @@
+package owl
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+)
+
+func TestEdwards25519Login(t *testing.T) {
+	client, server, serverRegistration := register(t, crypto.Edwards25519(), "alice", "password", WithVersion(LatestVersion))
+	login(t, client, server, serverRegistration)
+}
+
+// edwardsSmallOrder are points of order 2, 4 and 8 of edwards25519.
+var edwardsSmallOrder = []string{
+	"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+	"0000000000000000000000000000000000000000000000000000000000000080",
+	"26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc05",
+}
+
+// TestEdwards25519RejectsSmallOrderT registers a T of small order, and T
+// plus a point of small order, which the client could only compute without
+// knowing t.
+func TestEdwards25519RejectsSmallOrderT(t *testing.T) {
+	curve := crypto.Edwards25519()
+	client, err := ClientInit("alice", "password", "server", curve, WithVersion(LatestVersion))
+	if err != nil {
+		t.Fatal(err)
+	}
+	T, _ := crypto.DecodePoint(curve, client.Register().Payload.T)
+
+	for _, encoded := range edwardsSmallOrder {
+		order, _ := hex.DecodeString(encoded)
+		L, err := crypto.DecodePoint(curve, order)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, verifier := range []*crypto.Point{L, T.Add(L)} {
+			registration := *client.Register().Payload
+			registration.T = verifier.Encode()
+			if _, err := ServerInit("server", curve, &registration); err != crypto.ErrInvalidPoint {
+				t.Errorf("T = %x: got %v, want ErrInvalidPoint", registration.T, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package owl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventRegistration   EventType = "registration"
+	EventLoginSuccess   EventType = "login_success"
+	EventLoginFailure   EventType = "login_failure"
+	EventPasswordChange EventType = "password_change"
+	EventUsernameChange EventType = "username_change"
+	EventUpdateFailure  EventType = "credential_update_failure"
+)
+
+// FailureReason classifies why a login or credential update failed.
+type FailureReason string
+
+const (
+	FailureNone              FailureReason = ""
+	FailurePI1               FailureReason = "pi1_zkp"
+	FailurePI2               FailureReason = "pi2_zkp"
+	FailurePIAlpha           FailureReason = "pialpha_zkp"
+	FailurePI3               FailureReason = "pi3_zkp"
+	FailurePI4               FailureReason = "pi4_zkp"
+	FailurePIBeta            FailureReason = "pibeta_zkp"
+	FailureKCMismatch        FailureReason = "kc_mismatch"
+	FailureX1Mismatch        FailureReason = "x1_mismatch"
+	FailureThrottled         FailureReason = "throttled"
+	FailureLockedOut         FailureReason = "locked_out"
+	FailureUnknownServerName FailureReason = "unknown_server_name"
+	FailureRecordChanged     FailureReason = "record_changed"
+	FailureMalformed         FailureReason = "malformed_message"
+	FailureVersion           FailureReason = "version_mismatch"
+	FailureCodec             FailureReason = "codec_mismatch"
+	FailureHash              FailureReason = "hash_mismatch"
+	FailureOther             FailureReason = "other"
+)
+
+// Event describes an authentication outcome on the server. Events never
+// carry secrets: no passwords, keys, verifiers or protocol messages.
+type Event struct {
+	Type        EventType
+	Username    string
+	HandshakeID string
+	Ciphersuite string
+	Address     string
+	Time        time.Time
+	Duration    time.Duration
+	Failure     FailureReason
+}
+
+// EventHook receives the events emitted by a Server. HandleEvent is called
+// synchronously on the protocol path, so it should not block, and must be
+// safe for concurrent use when the Server is shared between goroutines.
+type EventHook interface {
+	HandleEvent(ctx context.Context, event Event)
+}
+
+// EventHookFunc adapts a function to the EventHook interface.
+type EventHookFunc func(ctx context.Context, event Event)
+
+func (hook EventHookFunc) HandleEvent(ctx context.Context, event Event) {
+	hook(ctx, event)
+}
+
+// SlogEventHook writes events to a log/slog Logger, successes at Info and
+// failures at Warn.
+type SlogEventHook struct {
+	Logger *slog.Logger
+}
+
+func NewSlogEventHook(logger *slog.Logger) *SlogEventHook {
+	return &SlogEventHook{Logger: logger}
+}
+
+func (hook *SlogEventHook) HandleEvent(ctx context.Context, event Event) {
+	level := slog.LevelInfo
+	if event.Failure != FailureNone {
+		level = slog.LevelWarn
+	}
+
+	attrs := []slog.Attr{
+		slog.String("event", string(event.Type)),
+		slog.String("username", event.Username),
+		slog.String("ciphersuite", event.Ciphersuite),
+		slog.Time("started", event.Time),
+		slog.Duration("duration", event.Duration),
+	}
+
+	if event.HandshakeID != "" {
+		attrs = append(attrs, slog.String("handshake_id", event.HandshakeID))
+	}
+
+	if event.Address != "" {
+		attrs = append(attrs, slog.String("address", event.Address))
+	}
+
+	if event.Failure != FailureNone {
+		attrs = append(attrs, slog.String("failure", string(event.Failure)))
+	}
+
+	hook.Logger.LogAttrs(ctx, level, "owl "+string(event.Type), attrs...)
+}
+
+// ClassifyFailure maps an error returned by the client or server to a
+// FailureReason.
+func ClassifyFailure(err error) FailureReason {
+	var throttled *ThrottledError
+
+	switch {
+	case err == nil:
+		return FailureNone
+	case errors.As(err, &throttled):
+		if throttled.LockedOut {
+			return FailureLockedOut
+		}
+		return FailureThrottled
+	case errors.Is(err, ErrPI1Verification):
+		return FailurePI1
+	case errors.Is(err, ErrPI2Verification):
+		return FailurePI2
+	case errors.Is(err, ErrPIAlphaVerification):
+		return FailurePIAlpha
+	case errors.Is(err, ErrPI3Verification):
+		return FailurePI3
+	case errors.Is(err, ErrPI4Verification):
+		return FailurePI4
+	case errors.Is(err, ErrPIBetaVerification):
+		return FailurePIBeta
+	case errors.Is(err, ErrClientKCTagMismatch), errors.Is(err, ErrServerKCTagMismatch):
+		return FailureKCMismatch
+	case errors.Is(err, ErrX1Mismatch):
+		return FailureX1Mismatch
+	case errors.Is(err, ErrUnknownServerName):
+		return FailureUnknownServerName
+	case errors.Is(err, ErrRecordChanged):
+		return FailureRecordChanged
+	case errors.Is(err, ErrMalformedMessage):
+		return FailureMalformed
+	case errors.Is(err, ErrVersionMismatch), errors.Is(err, ErrUnsupportedVersion):
+		return FailureVersion
+	case errors.Is(err, ErrCodecMismatch):
+		return FailureCodec
+	case errors.Is(err, ErrHashMismatch):
+		return FailureHash
+	default:
+		return FailureOther
+	}
+}
+
+func newHandshakeID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(id)
+}
+
+func (server *Server) emit(ctx context.Context, eventType EventType, handshakeID string, started time.Time, err error) {
+	server.emitFor(ctx, server.Username(), eventType, handshakeID, started, err)
+}
+
+func (server *Server) emitFor(ctx context.Context, user string, eventType EventType, handshakeID string, started time.Time, err error) {
+	hook := server.config.eventHook
+	if hook == nil {
+		return
+	}
+
+	hook.HandleEvent(ctx, Event{
+		Type:        eventType,
+		Username:    user,
+		HandshakeID: handshakeID,
+		Ciphersuite: server.ciphersuite(),
+		Address:     server.config.clientAddressOf(ctx),
+		Time:        started,
+		Duration:    time.Since(started),
+		Failure:     ClassifyFailure(err),
+	})
+}
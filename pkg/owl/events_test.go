@@ -0,0 +1,48 @@
+package owl
+
+import (
+	"context"
+	"crypto/elliptic"
+	"sync"
+	"testing"
+)
+
+func TestEventsCarryTheAddressOfEachCall(t *testing.T) {
+	var (
+		mutex     sync.Mutex
+		addresses = map[string]string{}
+	)
+	hook := EventHookFunc(func(_ context.Context, event Event) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		addresses[event.HandshakeID] = event.Address
+	})
+
+	curve := elliptic.P256()
+	client, server, serverRegistration := register(t, curve, "alice", "password", WithEventHook(hook))
+
+	// -- Two logins on the one Server, each from its own address
+	want := map[string]string{}
+	for _, address := range []string{"192.0.2.1", "192.0.2.2"} {
+		ctx := ContextWithClientAddress(context.Background(), address)
+		clientInit := client.AuthInit()
+		serverInit, err := server.AuthInitContext(ctx, serverRegistration, clientInit.Payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clientValidate, err := client.AuthValidate(clientInit, serverInit.Payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := server.AuthValidateContext(ctx, clientInit.Payload, clientValidate.Payload, serverInit); err != nil {
+			t.Fatal(err)
+		}
+		want[serverInit.HandshakeID] = address
+	}
+
+	for handshakeID, address := range want {
+		if addresses[handshakeID] != address {
+			t.Errorf("login %s logged address %q, want %q", handshakeID, addresses[handshakeID], address)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package owl
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"encoding/json"
+	"testing"
+)
+
+// -- Native fuzz targets, run one with: go test -fuzz FuzzServerAuthInit ./pkg/owl
+// The seed corpora under testdata/fuzz are the messages of real handshakes,
+// the targets also add the messages of the handshake they run against.
+
+// fuzzHandshake is a confirmed login, the fuzz targets feed mutations of its
+// messages to the side that receives them.
+type fuzzHandshake struct {
+	client             *Client
+	server             *Server
+	serverRegistration *RegistrationResponse
+	clientInit         *ClientAuthInitRequest
+	serverInit         *ServerAuthInitResponse
+	clientValidate     *ClientAuthValidateRequest
+	serverValidate     *ServerAuthValidateResponse
+	passwordChange     *PasswordChangeRequest
+	usernameChange     *UsernameChangeRequest
+}
+
+func newFuzzHandshake(t testing.TB, opts ...Option) *fuzzHandshake {
+	t.Helper()
+	handshake := &fuzzHandshake{}
+	handshake.client, handshake.server, handshake.serverRegistration = register(t, elliptic.P256(), "user", "password", opts...)
+
+	var err error
+	handshake.clientInit = handshake.client.AuthInit()
+	handshake.serverInit, err = handshake.server.AuthInit(handshake.serverRegistration, handshake.clientInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handshake.clientValidate, err = handshake.client.AuthValidate(handshake.clientInit, handshake.serverInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handshake.serverValidate, err = handshake.server.AuthValidate(handshake.clientInit.Payload, handshake.clientValidate.Payload, handshake.serverInit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = handshake.client.VerifyResponse(handshake.clientInit, handshake.clientValidate, handshake.serverInit.Payload, handshake.serverValidate.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handshake.passwordChange, err = handshake.client.ChangePassword(handshake.clientValidate, []byte("new password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handshake.usernameChange, err = handshake.client.ChangeUsername(handshake.clientValidate, "new user", []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return handshake
+}
+
+// payloads returns the payloads of the handshake, in the order of
+// fuzzPayloads.
+func (handshake *fuzzHandshake) payloads() []interface{} {
+	return []interface{}{
+		handshake.client.Register().Payload,
+		handshake.serverRegistration.Payload,
+		handshake.clientInit.Payload,
+		handshake.serverInit.Payload,
+		handshake.clientValidate.Payload,
+		handshake.serverValidate.Payload,
+		handshake.passwordChange.Payload,
+		handshake.usernameChange.Payload,
+	}
+}
+
+// fuzzPayloads returns an empty payload of each type a peer sends.
+func fuzzPayloads() []interface{} {
+	return []interface{}{
+		&RegistrationRequestPayload{},
+		&RegistrationResponsePayload{},
+		&ClientAuthInitRequestPayload{},
+		&ServerAuthInitResponsePayload{},
+		&ClientAuthValidateRequestPayload{},
+		&ServerAuthValidateResponsePayload{},
+		&PasswordChangeRequestPayload{},
+		&UsernameChangeRequestPayload{},
+	}
+}
+
+func mustMarshal(t testing.TB, payload interface{}) []byte {
+	t.Helper()
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+// newFuzzTarget runs the handshake of a fuzz target and seeds it with the
+// payload picked by seed.
+func newFuzzTarget(f *testing.F, seed func(handshake *fuzzHandshake) interface{}) *fuzzHandshake {
+	handshake := newFuzzHandshake(f, WithVersion(LatestVersion))
+	f.Add(mustMarshal(f, seed(handshake)))
+	return handshake
+}
+
+func FuzzDecodePayloads(f *testing.F) {
+	handshake := newFuzzHandshake(f, WithVersion(LatestVersion))
+	for kind, payload := range handshake.payloads() {
+		f.Add(uint8(kind), mustMarshal(f, payload))
+	}
+
+	f.Fuzz(func(t *testing.T, kind uint8, data []byte) {
+		payloads := fuzzPayloads()
+		payload := payloads[int(kind)%len(payloads)]
+		if json.Unmarshal(data, payload) != nil {
+			return
+		}
+
+		if validator, ok := payload.(interface{ validate() error }); ok {
+			_ = validator.validate()
+		}
+
+		// -- A decoded payload encodes and decodes again
+		if err := json.Unmarshal(mustMarshal(t, payload), fuzzPayloads()[int(kind)%len(payloads)]); err != nil {
+			t.Fatalf("decoded payload does not decode again: %v", err)
+		}
+	})
+}
+
+func FuzzServerInit(f *testing.F) {
+	handshake := newFuzzTarget(f, func(handshake *fuzzHandshake) interface{} {
+		return handshake.client.Register().Payload
+	})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var payload RegistrationRequestPayload
+		if json.Unmarshal(data, &payload) != nil {
+			return
+		}
+
+		server, err := ServerInit("server", elliptic.P256(), &payload)
+		if err == nil {
+			_, _ = server.AuthInit(server.RegisterUser(), handshake.clientInit.Payload)
+		}
+	})
+}
+
+func FuzzServerAuthInit(f *testing.F) {
+	handshake := newFuzzTarget(f, func(handshake *fuzzHandshake) interface{} {
+		return handshake.clientInit.Payload
+	})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var payload ClientAuthInitRequestPayload
+		if json.Unmarshal(data, &payload) != nil {
+			return
+		}
+		_, _ = handshake.server.AuthInit(handshake.serverRegistration, &payload)
+	})
+}
+
+// FuzzServerAuthValidate also checks that the server accepts no other r and
+// KC tag than the ones of the client that knows the password.
+func FuzzServerAuthValidate(f *testing.F) {
+	handshake := newFuzzTarget(f, func(handshake *fuzzHandshake) interface{} {
+		return handshake.clientValidate.Payload
+	})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var payload ClientAuthValidateRequestPayload
+		if json.Unmarshal(data, &payload) != nil {
+			return
+		}
+
+		_, err := handshake.server.AuthValidate(handshake.clientInit.Payload, &payload, handshake.serverInit)
+		if err != nil {
+			return
+		}
+
+		expected := handshake.clientValidate.Payload
+		if !bytes.Equal(payload.R, expected.R) || !bytes.Equal(payload.ClientKCTag, expected.ClientKCTag) {
+			t.Fatal("forged AuthValidate accepted")
+		}
+	})
+}
+
+func FuzzClientAuthValidate(f *testing.F) {
+	handshake := newFuzzTarget(f, func(handshake *fuzzHandshake) interface{} {
+		return handshake.serverInit.Payload
+	})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var payload ServerAuthInitResponsePayload
+		if json.Unmarshal(data, &payload) != nil {
+			return
+		}
+		_, _ = handshake.client.AuthValidate(handshake.clientInit, &payload)
+	})
+}
+
+// FuzzClientVerifyResponse also checks that the client accepts no other KC
+// tag than the one of the server that holds the record.
+func FuzzClientVerifyResponse(f *testing.F) {
+	handshake := newFuzzTarget(f, func(handshake *fuzzHandshake) interface{} {
+		return handshake.serverValidate.Payload
+	})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var payload ServerAuthValidateResponsePayload
+		if json.Unmarshal(data, &payload) != nil {
+			return
+		}
+
+		err := handshake.client.VerifyResponse(handshake.clientInit, handshake.clientValidate, handshake.serverInit.Payload, &payload)
+		if err == nil && !bytes.Equal(payload.ServerKCTag, handshake.serverValidate.Payload.ServerKCTag) {
+			t.Fatal("forged ServerKCTag accepted")
+		}
+	})
+}
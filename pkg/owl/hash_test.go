@@ -0,0 +1,53 @@
+package owl
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"testing"
+
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+)
+
+func TestHashIsFixedAtRegistration(t *testing.T) {
+	curve := elliptic.P384()
+	for _, hash := range crypto.HashFunctions {
+		t.Run(hash.String(), func(t *testing.T) {
+			client, server, serverRegistration := register(t, curve, "alice", "password", WithHash(hash))
+			if server.UserRegistration.Hash != hash {
+				t.Fatalf("record registered with %s", server.UserRegistration.Hash)
+			}
+			clientValidate, _ := login(t, client, server, serverRegistration)
+			if len(clientValidate.Payload.ClientKCTag) != hash.Size() {
+				t.Errorf("KC tag of %d bytes", len(clientValidate.Payload.ClientKCTag))
+			}
+		})
+	}
+
+	// -- The record decides, a server option does not change it
+	client, err := ClientInit("bob", "password", "server", curve, WithHash(crypto.SHA384))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ServerInit("server", curve, client.Register().Payload, WithHash(crypto.SHA256))
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverRegistration := server.RegisterUser()
+	login(t, client, server, serverRegistration)
+
+	// -- A client with another hash function than its record is refused
+	other, err := ClientInit("bob", "password", "server", curve, WithHash(crypto.SHA512))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = server.AuthInit(serverRegistration, other.AuthInit().Payload)
+	if !errors.Is(err, ErrHashMismatch) || ClassifyFailure(err) != FailureHash {
+		t.Fatalf("hash mismatch: got %v", err)
+	}
+
+	registration := client.Register().Payload
+	registration.Hash = crypto.SHA3_512 + 1
+	if _, err := ServerInit("server", curve, registration); !errors.Is(err, crypto.ErrUnsupportedHash) {
+		t.Fatalf("unsupported hash: got %v", err)
+	}
+}
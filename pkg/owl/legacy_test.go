@@ -0,0 +1,78 @@
+package owl
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+)
+
+// -- Golden values of the original implementation, the one VersionLegacy
+// must reproduce byte for byte. They were computed with the baseline
+// crypto.Hash on P-256 from the inputs below.
+
+// legacyVerifiers are t and π of ClientInit. The t of alice starts below
+// 0x80 and the one of bob above, the two branches of the sign byte the
+// legacy hash writes before a big int.
+var legacyVerifiers = []struct {
+	user, pass string
+	t, π       string
+}{
+	{"alice", "password", "1be1adf7609698b466a486084b8f20f406fe2abe49686b4256cc047f1c84eb20", "a7fa041e3b736e2b0926943fdc37f555516357233e523f17bbe0c258e603753b"},
+	{"bob", "hunter2", "d14f6314ecf424aed7a3e7527dc2f7f5ca2090fb695c431c0da4fb9c917d6ad7", "8a316464fc8f91f107e36006a3700494872e8a9115c7628eff97635868f5c40c"},
+}
+
+// legacyTranscript is the transcript hash of AuthValidate, modulo N, and
+// legacySessionKey the session key, for the raw key 7·G and the messages
+// of legacyMessages.
+const (
+	legacyTranscript = "8b933ce519b2beb4e8dc7b23437ffb45a87f72f70df5035027983b1cf5f18386"
+	legacySessionKey = "52b683c2f54896970584249685a4f3c46c624ae01b81b44533fad61403fd4e56"
+)
+
+// legacyMessages are messages of a login made of multiples of G: X1 to X4,
+// β and α are 11·G to 16·G, the proofs have V = 21·G to 26·G and r = 31 to
+// 36.
+func legacyMessages(curve elliptic.Curve) (*ClientAuthInitRequestPayload, *ServerAuthInitResponsePayload, []byte, *crypto.SchnorrZKP) {
+	P := func(k int64) []byte { return crypto.MultiplyG(curve, big.NewInt(k)) }
+	zkp := func(k int64, r int64) *crypto.SchnorrZKP { return &crypto.SchnorrZKP{V: P(k), R: big.NewInt(r)} }
+
+	clientInit := &ClientAuthInitRequestPayload{U: "alice", X1: P(11), X2: P(12), PI1: zkp(21, 31), PI2: zkp(22, 32)}
+	serverInit := &ServerAuthInitResponsePayload{X3: P(13), X4: P(14), PI3: zkp(23, 33), PI4: zkp(24, 34), Beta: P(15), PIBeta: zkp(25, 35)}
+	return clientInit, serverInit, P(16), zkp(26, 36)
+}
+
+func checkGolden(t *testing.T, name string, got *big.Int, want string) {
+	t.Helper()
+	if hex.EncodeToString(got.Bytes()) != want {
+		t.Errorf("%s\n  got  %x\n  want %s", name, got.Bytes(), want)
+	}
+}
+
+func TestVersionLegacyMatchesBaseline(t *testing.T) {
+	curve := elliptic.P256()
+	for _, vector := range legacyVerifiers {
+		client, err := ClientInit(vector.user, vector.pass, "server", curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		π, err := client.Register().Payload.PI.Decode(curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, vector.user+" t", client.t, vector.t)
+		checkGolden(t, vector.user+" π", π, vector.π)
+	}
+
+	suite := VersionLegacy.suite(curve, crypto.SHA256, crypto.CodecCompressed)
+	rawKey := crypto.MultiplyG(curve, big.NewInt(7))
+	clientInit, serverInit, α, PIAlpha := legacyMessages(curve)
+	h, err := transcriptHash(suite, rawKey, "alice", clientInit, "server", serverInit, α, PIAlpha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "transcript hash", h, legacyTranscript)
+	checkGolden(t, "session key", deriveKey(suite, rawKey, SessionKey), legacySessionKey)
+}
@@ -3,6 +3,7 @@ package owl
 import (
 	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
 	"math/big"
+	"time"
 )
 
 //
@@ -10,9 +11,12 @@ import (
 //
 
 type RegistrationRequestPayload struct {
-	U  string
-	PI *big.Int
-	T  []byte
+	U       string
+	PI      crypto.Scalar
+	T       []byte
+	Version Version
+	Codec   crypto.PointCodec
+	Hash    crypto.HashFunction
 }
 
 type RegistrationRequest struct {
@@ -20,33 +24,107 @@ type RegistrationRequest struct {
 	t       *big.Int
 }
 
+// Destroy wipes t, the request must not be used afterwards.
+func (request *RegistrationRequest) Destroy() {
+	crypto.ZeroBigInt(request.t)
+}
+
 type ClientAuthInitRequestPayload struct {
-	U   string
-	X1  []byte
-	X2  []byte
-	PI1 *crypto.SchnorrZKP
-	PI2 *crypto.SchnorrZKP
+	U       string
+	S       string // Server name the client expects, empty means the current one
+	X1      []byte
+	X2      []byte
+	PI1     *crypto.SchnorrZKP
+	PI2     *crypto.SchnorrZKP
+	Version Version
+	Codec   crypto.PointCodec
+	Hash    crypto.HashFunction
 }
 
 type ClientAuthInitRequest struct {
 	Payload *ClientAuthInitRequestPayload
 	x1      *big.Int
 	x2      *big.Int
+
+	// -- Payload.X1 and Payload.X2, kept decoded for AuthValidate
+	pointX1 *crypto.Point
+	pointX2 *crypto.Point
+}
+
+// Destroy wipes the ephemeral keys x1 and x2, the request must not be used
+// afterwards.
+func (request *ClientAuthInitRequest) Destroy() {
+	crypto.ZeroBigInt(request.x1)
+	crypto.ZeroBigInt(request.x2)
 }
 
 type ClientAuthValidateRequestPayload struct {
-	ClientKCTag *big.Int
+	ClientKCTag []byte
 	Alpha       []byte
 	PIAlpha     *crypto.SchnorrZKP
-	R           *big.Int
+	R           crypto.Scalar
 }
 
 type ClientAuthValidateRequest struct {
 	Payload          *ClientAuthValidateRequestPayload
-	RawClientKey     []byte
 	ClientSessionKey *big.Int
-	ClientKCKey      *big.Int
 	HTranscript      *big.Int
+
+	init         *ClientAuthInitRequest
+	rawClientKey []byte
+	kcKey        *big.Int
+	sessionKey   *big.Int
+	strict       bool
+	confirmed    bool
+}
+
+// SessionKey returns the client session key. With strict key confirmation
+// the key is withheld until VerifyResponse has accepted the servers KC tag.
+func (request *ClientAuthValidateRequest) SessionKey() (*big.Int, error) {
+	if request.strict && !request.confirmed {
+		return nil, ErrKeyNotConfirmed
+	}
+	return request.sessionKey, nil
+}
+
+// Destroy wipes the raw key, the KC key and the session key, copy the
+// session key out before calling it.
+func (request *ClientAuthValidateRequest) Destroy() {
+	crypto.ZeroBytes(request.rawClientKey)
+	crypto.ZeroBigInt(request.kcKey)
+	crypto.ZeroBigInt(request.sessionKey)
+	request.ClientSessionKey = nil
+}
+
+type PasswordChangeRequestPayload struct {
+	U   string
+	PI  crypto.Scalar
+	T   []byte
+	Tag []byte
+}
+
+type PasswordChangeRequest struct {
+	Payload *PasswordChangeRequestPayload
+
+	// Client is bound to the new password, use it for logins once the
+	// server has accepted the change.
+	Client *Client
+}
+
+type UsernameChangeRequestPayload struct {
+	U    string
+	NewU string
+	PI   crypto.Scalar
+	T    []byte
+	Tag  []byte
+}
+
+type UsernameChangeRequest struct {
+	Payload *UsernameChangeRequestPayload
+
+	// Client is bound to the new username, use it for logins once the
+	// server has accepted the change.
+	Client *Client
 }
 
 //
@@ -60,6 +138,15 @@ type RegistrationResponsePayload struct {
 
 type RegistrationResponse struct {
 	Payload *RegistrationResponsePayload
+	Legacy  *LegacyServerName
+}
+
+// LegacyServerName is the registration a record keeps under the servers
+// previous name, it is accepted until Until (see Server.MigrateServerName).
+type LegacyServerName struct {
+	Name         string
+	Until        time.Time
+	Registration *RegistrationResponse
 }
 
 type ServerAuthInitResponsePayload struct {
@@ -72,19 +159,79 @@ type ServerAuthInitResponsePayload struct {
 }
 
 type ServerAuthInitResponse struct {
-	Payload *ServerAuthInitResponsePayload
-	Xx4     *big.Int
-	GBeta   []byte
+	Payload     *ServerAuthInitResponsePayload
+	Xx4         *big.Int
+	GBeta       []byte
+	ServerName  string
+	HandshakeID string
+}
+
+func (response *ServerAuthInitResponse) handshakeID() string {
+	if response == nil {
+		return ""
+	}
+	return response.HandshakeID
+}
+
+// Destroy wipes the servers ephemeral key x4 once the handshake is over, the
+// response must not be used afterwards.
+func (response *ServerAuthInitResponse) Destroy() {
+	crypto.ZeroBigInt(response.Xx4)
 }
 
 type ServerAuthValidateResponsePayload struct {
-	ServerKCTag *big.Int
+	ServerKCTag []byte
 }
 
 type ServerAuthValidateResponse struct {
 	Payload          *ServerAuthValidateResponsePayload
-	RawServerKey     []byte
 	ServerSessionKey *big.Int
-	ServerKCKey      *big.Int
 	HTranscript      *big.Int
+	HandshakeID      string
+
+	serverName   string
+	rawServerKey []byte
+
+	// -- The user and record the login was made against, a credential
+	// update is only applied to them
+	user         string
+	registration *RegistrationRequestPayload
+
+	kcKey              *big.Int
+	sessionKey         *big.Int
+	strict             bool
+	sent               bool
+	credentialsUpdated bool
+}
+
+func (response *ServerAuthValidateResponse) handshakeID() string {
+	if response == nil {
+		return ""
+	}
+	return response.HandshakeID
+}
+
+// ConfirmationSent records that the payload carrying the ServerKCTag has been
+// sent to the client, in strict mode this releases the server session key.
+func (response *ServerAuthValidateResponse) ConfirmationSent() {
+	response.sent = true
+	response.ServerSessionKey = response.sessionKey
+}
+
+// SessionKey returns the server session key. With strict key confirmation
+// the key is withheld until ConfirmationSent has been called.
+func (response *ServerAuthValidateResponse) SessionKey() (*big.Int, error) {
+	if response.strict && !response.sent {
+		return nil, ErrKeyNotConfirmed
+	}
+	return response.sessionKey, nil
+}
+
+// Destroy wipes the raw key, the KC key and the session key, copy the
+// session key out before calling it.
+func (response *ServerAuthValidateResponse) Destroy() {
+	crypto.ZeroBytes(response.rawServerKey)
+	crypto.ZeroBigInt(response.kcKey)
+	crypto.ZeroBigInt(response.sessionKey)
+	response.ServerSessionKey = nil
 }
@@ -0,0 +1,236 @@
+package owl
+
+import (
+	"encoding/json"
+	"expvar"
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type Operation string
+
+const (
+	OperationRegisterUser Operation = "register_user"
+	OperationAuthInit     Operation = "auth_init"
+	OperationAuthValidate Operation = "auth_validate"
+
+	// -- The verification of each proof
+	OperationVerifyPI1     Operation = "verify_zkp/pi1"
+	OperationVerifyPI2     Operation = "verify_zkp/pi2"
+	OperationVerifyPI3     Operation = "verify_zkp/pi3"
+	OperationVerifyPI4     Operation = "verify_zkp/pi4"
+	OperationVerifyPIBeta  Operation = "verify_zkp/pi_beta"
+	OperationVerifyPIAlpha Operation = "verify_zkp/pi_alpha"
+
+	// -- The combined check of the proofs of a step, see
+	// crypto.Suite.VerifyZKPCombined
+	OperationVerifyZKPs Operation = "verify_zkp_batch"
+)
+
+// Metrics records how long protocol operations take and why they fail,
+// per ciphersuite. Implementations must be safe for concurrent use.
+type Metrics interface {
+	ObserveDuration(operation Operation, ciphersuite string, duration time.Duration)
+	CountFailure(operation Operation, ciphersuite string, reason FailureReason)
+}
+
+// NoopMetrics discards everything, it is the default.
+type NoopMetrics struct{}
+
+func (NoopMetrics) ObserveDuration(Operation, string, time.Duration) {}
+
+func (NoopMetrics) CountFailure(Operation, string, FailureReason) {}
+
+// DurationBuckets are the upper bounds of the ExpvarMetrics histograms, an
+// observation is counted in the first bucket it does not exceed (buckets are
+// not cumulative).
+var DurationBuckets = []time.Duration{
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+}
+
+// ExpvarMetrics publishes the metrics through expvar (/debug/vars) as
+//
+//	<name>.durations["<operation>/<ciphersuite>"] -> histogram
+//	<name>.failures["<operation>/<ciphersuite>/<reason>"] -> counter
+type ExpvarMetrics struct {
+	durations *expvar.Map
+	failures  *expvar.Map
+	mutex     sync.Mutex
+}
+
+// NewExpvarMetrics publishes a new expvar.Map called name, like
+// expvar.Publish it panics if name is already in use.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	metrics := &ExpvarMetrics{
+		durations: new(expvar.Map).Init(),
+		failures:  new(expvar.Map).Init(),
+	}
+
+	root := new(expvar.Map).Init()
+	root.Set("durations", metrics.durations)
+	root.Set("failures", metrics.failures)
+	expvar.Publish(name, root)
+
+	return metrics
+}
+
+func (metrics *ExpvarMetrics) ObserveDuration(operation Operation, ciphersuite string, duration time.Duration) {
+	key := string(operation) + "/" + ciphersuite
+
+	histogram, ok := metrics.durations.Get(key).(*durationHistogram)
+	if !ok {
+		// -- Created under a lock so two goroutines do not race to Set it
+		metrics.mutex.Lock()
+		histogram, ok = metrics.durations.Get(key).(*durationHistogram)
+		if !ok {
+			histogram = newDurationHistogram()
+			metrics.durations.Set(key, histogram)
+		}
+		metrics.mutex.Unlock()
+	}
+
+	histogram.observe(duration)
+}
+
+func (metrics *ExpvarMetrics) CountFailure(operation Operation, ciphersuite string, reason FailureReason) {
+	metrics.failures.Add(string(operation)+"/"+ciphersuite+"/"+string(reason), 1)
+}
+
+type durationHistogram struct {
+	mutex   sync.Mutex
+	count   int64
+	sum     time.Duration
+	buckets []int64 // Last one counts everything above DurationBuckets
+}
+
+func newDurationHistogram() *durationHistogram {
+	return &durationHistogram{
+		buckets: make([]int64, len(DurationBuckets)+1),
+	}
+}
+
+func (histogram *durationHistogram) observe(duration time.Duration) {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	histogram.count++
+	histogram.sum += duration
+
+	for i, bound := range DurationBuckets {
+		if duration <= bound {
+			histogram.buckets[i]++
+			return
+		}
+	}
+	histogram.buckets[len(DurationBuckets)]++
+}
+
+// String implements expvar.Var.
+func (histogram *durationHistogram) String() string {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	buckets := make(map[string]int64, len(histogram.buckets))
+	for i, bound := range DurationBuckets {
+		buckets[strconv.FormatFloat(bound.Seconds(), 'f', -1, 64)] = histogram.buckets[i]
+	}
+	buckets["+Inf"] = histogram.buckets[len(DurationBuckets)]
+
+	encoded, _ := json.Marshal(struct {
+		Count      int64            `json:"count"`
+		SumSeconds float64          `json:"sum_seconds"`
+		Buckets    map[string]int64 `json:"buckets"`
+	}{histogram.count, histogram.sum.Seconds(), buckets})
+
+	return string(encoded)
+}
+
+func (cfg *config) observe(operation Operation, ciphersuite string, started time.Time, err error) {
+	cfg.metrics.ObserveDuration(operation, ciphersuite, time.Since(started))
+	if err != nil {
+		cfg.metrics.CountFailure(operation, ciphersuite, ClassifyFailure(err))
+	}
+}
+
+// verifyZKP is suite.VerifyZKP with its duration and failures recorded
+// under the operation of the proof.
+func (cfg *config) verifyZKP(ciphersuite string, suite *crypto.Suite, check zkpCheck) error {
+	started := time.Now()
+	var err error
+	statement := check.statement
+	if !suite.VerifyZKP(statement.Generator, statement.X, statement.ZKP, statement.Prover) {
+		err = check.failure
+	}
+
+	cfg.observe(check.operation, ciphersuite, started, err)
+	return err
+}
+
+// zkpCheck is a proof to verify, the operation it is recorded under and the
+// error reported when it is rejected.
+type zkpCheck struct {
+	statement crypto.ZKPStatement
+	operation Operation
+	failure   error
+}
+
+// verifyZKPs verifies independent proofs, each recorded under its own
+// operation, in parallel with WithParallelVerification. Where
+// crypto.VerifyZKPBatch combines proofs, they are first checked as one
+// combination, recorded as OperationVerifyZKPs, and only verified one by
+// one to find the invalid proof if it fails. A rejection is reported with
+// the failure of the first invalid proof.
+func (cfg *config) verifyZKPs(ciphersuite string, suite *crypto.Suite, checks ...zkpCheck) error {
+	verify := func(i int) bool {
+		return cfg.verifyZKP(ciphersuite, suite, checks[i]) == nil
+	}
+
+	if cfg.verifiers != nil {
+		if failed, ok := cfg.verifiers.verify(len(checks), verify); !ok {
+			return checks[failed].failure
+		}
+		return nil
+	}
+
+	if len(checks) < 2 || !suite.CombinesZKPs() {
+		for i, check := range checks {
+			if !verify(i) {
+				return check.failure
+			}
+		}
+		return nil
+	}
+
+	started := time.Now()
+	statements := make([]crypto.ZKPStatement, len(checks))
+	for i, check := range checks {
+		statements[i] = check.statement
+	}
+	valid := suite.VerifyZKPCombined(statements)
+	cfg.observe(OperationVerifyZKPs, ciphersuite, started, nil)
+	if valid {
+		return nil
+	}
+
+	// -- At least one proof is invalid, if it is not one of the others it
+	// is the last
+	last := len(checks) - 1
+	for i, check := range checks[:last] {
+		if !verify(i) {
+			return check.failure
+		}
+	}
+	cfg.metrics.CountFailure(checks[last].operation, ciphersuite, ClassifyFailure(checks[last].failure))
+	return checks[last].failure
+}
@@ -0,0 +1,94 @@
+package owl
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+)
+
+// recordingMetrics counts the observations and failures of each operation.
+type recordingMetrics struct {
+	mutex     sync.Mutex
+	durations map[Operation]int
+	failures  map[Operation]FailureReason
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{durations: make(map[Operation]int), failures: make(map[Operation]FailureReason)}
+}
+
+func (metrics *recordingMetrics) ObserveDuration(operation Operation, _ string, _ time.Duration) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.durations[operation]++
+}
+
+func (metrics *recordingMetrics) CountFailure(operation Operation, _ string, reason FailureReason) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.failures[operation] = reason
+}
+
+func TestMetricsRecordEachProof(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		curve elliptic.Curve
+		opts  []Option
+	}{
+		{"P-256", elliptic.P256(), nil},
+		{"P-256 parallel", elliptic.P256(), []Option{WithParallelVerification(2)}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			metrics := newRecordingMetrics()
+			opts := append([]Option{WithMetrics(metrics)}, test.opts...)
+			client, server, serverRegistration := register(t, test.curve, "alice", "password", opts...)
+			login(t, client, server, serverRegistration)
+
+			for _, operation := range []Operation{
+				OperationVerifyPI1, OperationVerifyPI2, OperationVerifyPI3,
+				OperationVerifyPI4, OperationVerifyPIBeta, OperationVerifyPIAlpha,
+			} {
+				if metrics.durations[operation] != 1 {
+					t.Errorf("%s recorded %d times", operation, metrics.durations[operation])
+				}
+			}
+			if metrics.durations[OperationVerifyZKPs] != 0 {
+				t.Error("proofs were recorded as a combination")
+			}
+		})
+	}
+}
+
+func TestMetricsRecordCombinedProofs(t *testing.T) {
+	metrics := newRecordingMetrics()
+	client, server, serverRegistration := register(t, crypto.Secp256k1(), "alice", "password", WithMetrics(metrics))
+	login(t, client, server, serverRegistration)
+
+	// -- PI1 and PI2, then PI3, PI4 and PIBeta
+	if metrics.durations[OperationVerifyZKPs] != 2 {
+		t.Errorf("%d combinations recorded, want 2", metrics.durations[OperationVerifyZKPs])
+	}
+	if metrics.durations[OperationVerifyPIAlpha] != 1 {
+		t.Error("PIAlpha was not recorded")
+	}
+
+	// -- A failed combination records the invalid proof under its own operation
+	for _, tampered := range []int{1, 2} {
+		clientInit := client.AuthInit()
+		proof := clientInit.Payload.PI1
+		if tampered == 2 {
+			proof = clientInit.Payload.PI2
+		}
+		proof.R = new(big.Int).Add(proof.R, big.NewInt(1))
+		if _, err := server.AuthInit(serverRegistration, clientInit.Payload); err == nil {
+			t.Fatalf("tampered PI%d accepted", tampered)
+		}
+	}
+	if metrics.failures[OperationVerifyPI1] != FailurePI1 || metrics.failures[OperationVerifyPI2] != FailurePI2 {
+		t.Errorf("failures recorded as %v", metrics.failures)
+	}
+}
@@ -0,0 +1,153 @@
+package owl
+
+import (
+	"context"
+
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+)
+
+// Option configures optional behaviour of a Client or Server, it is passed
+// to ClientInit / ServerInit. Options that only make sense for one side are
+// ignored by the other.
+type Option func(*config)
+
+type config struct {
+	strictKeyConfirmation bool
+	throttler             *Throttler
+	clientAddress         string
+	eventHook             EventHook
+	metrics               Metrics
+	verifiers             *verifierPool
+	version               Version
+	hash                  crypto.HashFunction
+	codec                 crypto.PointCodec
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{
+		metrics: NoopMetrics{},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// withConfig copies every setting of cfg, for a client that replaces
+// another one and derives its verifier the same way.
+func withConfig(cfg config) Option {
+	return func(target *config) {
+		*target = cfg
+	}
+}
+
+// WithStrictKeyConfirmation turns key confirmation into a mandatory step.
+//
+// On the client the session key is only released once VerifyResponse has
+// checked the servers KC tag. On the server the session key is withheld
+// until the caller reports (ConfirmationSent) that the KC tag was sent.
+func WithStrictKeyConfirmation() Option {
+	return func(cfg *config) {
+		cfg.strictKeyConfirmation = true
+	}
+}
+
+// WithThrottler makes the server refuse logins the Throttler does not allow,
+// before any ZKP is verified, and report every failed AuthValidate to it.
+func WithThrottler(throttler *Throttler) Option {
+	return func(cfg *config) {
+		cfg.throttler = throttler
+	}
+}
+
+// WithClientAddress sets the address of the client the server is talking
+// to, the Throttler counts failures per address as well as per username.
+//
+// Deprecated: a Server is shared by every request of its user, pass the
+// address of each request with ContextWithClientAddress instead. The
+// address set here is only used for calls whose context carries none.
+func WithClientAddress(address string) Option {
+	return func(cfg *config) {
+		cfg.clientAddress = address
+	}
+}
+
+type clientAddressKey struct{}
+
+// ContextWithClientAddress returns a copy of ctx carrying the address of the
+// client of one request. Pass it to AuthInitContext and AuthValidateContext,
+// the Throttler counts failures per address as well as per username.
+func ContextWithClientAddress(ctx context.Context, address string) context.Context {
+	return context.WithValue(ctx, clientAddressKey{}, address)
+}
+
+// clientAddressOf is the address carried by ctx, or the one of
+// WithClientAddress.
+func (cfg *config) clientAddressOf(ctx context.Context) string {
+	if address, ok := ctx.Value(clientAddressKey{}).(string); ok {
+		return address
+	}
+	return cfg.clientAddress
+}
+
+// WithEventHook makes the server report registrations, logins (with their
+// failure reason) and credential updates to hook.
+func WithEventHook(hook EventHook) Option {
+	return func(cfg *config) {
+		cfg.eventHook = hook
+	}
+}
+
+// WithMetrics records the duration and failures of RegisterUser, AuthInit,
+// AuthValidate and of every ZKP verification in metrics.
+func WithMetrics(metrics Metrics) Option {
+	return func(cfg *config) {
+		cfg.metrics = metrics
+	}
+}
+
+// WithParallelVerification verifies the independent proofs of a step (PI1
+// and PI2 on the server, PI3, PI4 and PIBeta on the client) concurrently
+// rather than as one batch, on at most workers extra goroutines (GOMAXPROCS
+// if workers < 1). This lowers the latency of a handshake when there are
+// idle cores, on secp256k1 at the price of more total work than batch
+// verification (see crypto.VerifyZKPBatch).
+//
+// The limit is shared by every Client and Server configured with the same
+// Option value; a proof that finds no free worker is verified on the calling
+// goroutine.
+func WithParallelVerification(workers int) Option {
+	pool := newVerifierPool(workers)
+	return func(cfg *config) {
+		cfg.verifiers = pool
+	}
+}
+
+// WithVersion selects the protocol version of a client, it must be the
+// version its record was registered with. Servers ignore it, they use the
+// version of the record.
+func WithVersion(version Version) Option {
+	return func(cfg *config) {
+		cfg.version = version
+	}
+}
+
+// WithHash selects the hash function of a clients transcripts, KC tags and
+// key schedule, SHA-256 by default. Like the point encoding it is fixed at
+// registration and sent with every login. Servers ignore it, they use the
+// hash function of the record.
+func WithHash(hash crypto.HashFunction) Option {
+	return func(cfg *config) {
+		cfg.hash = hash
+	}
+}
+
+// WithPointCodec selects how a client encodes points: in its messages, in
+// transcripts and so in the KC tags, compressed by default. Like the
+// version it is fixed at registration and sent with every login. Servers
+// ignore it, they use the encoding of the record.
+func WithPointCodec(codec crypto.PointCodec) Option {
+	return func(cfg *config) {
+		cfg.codec = codec
+	}
+}
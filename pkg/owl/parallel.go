@@ -0,0 +1,58 @@
+package owl
+
+import (
+	"runtime"
+	"sync"
+)
+
+// verifierPool bounds the goroutines used to verify proofs in parallel, it
+// is shared by every Client and Server configured with the same Option.
+type verifierPool struct {
+	slots chan struct{}
+}
+
+func newVerifierPool(workers int) *verifierPool {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &verifierPool{slots: make(chan struct{}, workers)}
+}
+
+// verify calls verify for 0 to count - 1, each on a worker if one is free
+// and on the calling goroutine otherwise. Like crypto.VerifyZKPBatch it
+// returns the index of the first invalid proof.
+func (pool *verifierPool) verify(count int, verify func(i int) bool) (int, bool) {
+	valid := make([]bool, count)
+	var wait sync.WaitGroup
+
+	for i := 0; i < count; i++ {
+		i := i
+
+		// -- The last proof is verified here, the caller would only be waiting
+		if i < count-1 {
+			select {
+			case pool.slots <- struct{}{}:
+				wait.Add(1)
+				go func() {
+					defer func() {
+						<-pool.slots
+						wait.Done()
+					}()
+					valid[i] = verify(i)
+				}()
+				continue
+			default:
+			}
+		}
+
+		valid[i] = verify(i)
+	}
+
+	wait.Wait()
+	for i, ok := range valid {
+		if !ok {
+			return i, false
+		}
+	}
+	return -1, true
+}
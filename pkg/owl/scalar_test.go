@@ -0,0 +1,134 @@
+package owl
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+)
+
+// invalidScalars returns scalars of N or more, and scalar without its first
+// byte and with one more, the forms a scalar of a payload must not take.
+func invalidScalars(curve elliptic.Curve, scalar crypto.Scalar) map[string]crypto.Scalar {
+	size := crypto.ScalarSize(curve)
+	return map[string]crypto.Scalar{
+		"N":     curve.Params().N.FillBytes(make([]byte, size)),
+		"max":   bytes.Repeat([]byte{0xff}, size),
+		"short": scalar[1:],
+		"long":  append(make(crypto.Scalar, 1), scalar...),
+	}
+}
+
+func TestPayloadScalarsAreStrict(t *testing.T) {
+	curve := elliptic.P256()
+	client, server, serverRegistration := register(t, curve, "alice", "password", WithVersion(LatestVersion))
+
+	for name, PI := range invalidScalars(curve, client.Register().Payload.PI) {
+		registration := *client.Register().Payload
+		registration.PI = PI
+		if _, err := ServerInit("server", curve, &registration); !errors.Is(err, ErrMalformedMessage) {
+			t.Errorf("registration with PI %s: got %v", name, err)
+		}
+	}
+
+	clientInit := client.AuthInit()
+	serverInit, err := server.AuthInit(serverRegistration, clientInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientValidate, err := client.AuthValidate(clientInit, serverInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, R := range invalidScalars(curve, clientValidate.Payload.R) {
+		payload := *clientValidate.Payload
+		payload.R = R
+		if _, err := server.AuthValidate(clientInit.Payload, &payload, serverInit); !errors.Is(err, ErrMalformedMessage) {
+			t.Errorf("login with r %s: got %v", name, err)
+		}
+	}
+
+	// -- From Version1 on a KC tag is compared as sent, never padded
+	for name, tag := range map[string][]byte{
+		"truncated":   clientValidate.Payload.ClientKCTag[1:],
+		"left-padded": append(make([]byte, 1), clientValidate.Payload.ClientKCTag...),
+	} {
+		payload := *clientValidate.Payload
+		payload.ClientKCTag = tag
+		if _, err := server.AuthValidate(clientInit.Payload, &payload, serverInit); !errors.Is(err, ErrClientKCTagMismatch) {
+			t.Errorf("login with a %s KC tag: got %v", name, err)
+		}
+	}
+	if _, err := server.AuthValidate(clientInit.Payload, clientValidate.Payload, serverInit); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCredentialUpdateScalarsAreStrict(t *testing.T) {
+	curve := elliptic.P256()
+	client, server, serverRegistration := register(t, curve, "alice", "password")
+	store := NewMemoryCredentialStore()
+	store.Store(server.Registration())
+
+	clientValidate, serverValidate := login(t, client, server, serverRegistration)
+	change, err := client.ChangePassword(clientValidate, []byte("new password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, PI := range invalidScalars(curve, change.Payload.PI) {
+		request := *change.Payload
+		request.PI = PI
+		request.Tag = credentialUpdateTag(client.suite(), clientValidate.rawClientKey, PasswordChangeKeyTag,
+			"alice", "server", request.PI, request.T)
+		if _, err := server.ChangePassword(store, serverValidate, &request); err == nil {
+			t.Errorf("password change with PI %s applied", name)
+		}
+	}
+
+	if _, err := server.ChangePassword(store, serverValidate, change.Payload); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestLegacyKCTagIsPadded checks that a VersionLegacy server accepts a KC tag
+// sent like the TS client sends it, as a big int without its leading zero
+// bytes, and that a later version refuses it.
+func TestLegacyKCTagIsPadded(t *testing.T) {
+	for _, version := range []Version{VersionLegacy, LatestVersion} {
+		client, server, serverRegistration := register(t, elliptic.P256(), "alice", "password", WithVersion(version))
+
+		// -- About 1 login in 256 has a tag starting with a zero byte
+		for attempt := 0; ; attempt++ {
+			if attempt == 4096 {
+				t.Fatal("no KC tag with a leading zero byte")
+			}
+			clientInit := client.AuthInit()
+			serverInit, err := server.AuthInit(serverRegistration, clientInit.Payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			clientValidate, err := client.AuthValidate(clientInit, serverInit.Payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if clientValidate.Payload.ClientKCTag[0] != 0 {
+				continue
+			}
+
+			payload := *clientValidate.Payload
+			payload.ClientKCTag = new(big.Int).SetBytes(payload.ClientKCTag).Bytes()
+			_, err = server.AuthValidate(clientInit.Payload, &payload, serverInit)
+			if version == VersionLegacy && err != nil {
+				t.Errorf("legacy login with a KC tag without its leading zeros: %v", err)
+			}
+			if version != VersionLegacy && !errors.Is(err, ErrClientKCTagMismatch) {
+				t.Errorf("version %d login with a KC tag without its leading zeros: got %v", version, err)
+			}
+			break
+		}
+	}
+}
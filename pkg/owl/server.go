@@ -1,43 +1,142 @@
 package owl
 
 import (
+	"context"
 	"crypto/elliptic"
 	"errors"
 	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+	"sync"
+	"time"
 )
 
+// Server runs the server side of the protocol for one user. It is safe for
+// concurrent use: any number of handshakes can run on it at once, and
+// ChangePassword / ChangeUsername update UserRegistration / UserIdentifier
+// under a lock (handshakes in flight then fail). While the server is in use
+// read them with Username and Registration, and do not modify any exported
+// field.
+//
+// The per-handshake objects (ServerAuthInitResponse,
+// ServerAuthValidateResponse) are not safe for concurrent use.
 type Server struct {
 	UserIdentifier   string
 	ServerName       string
 	Curve            elliptic.Curve
 	CurveParams      *elliptic.CurveParams
 	UserRegistration *RegistrationRequestPayload
+
+	config config
+	mutex  sync.RWMutex
 }
 
 func ServerInit(
 	server string,
 	curve elliptic.Curve,
 	userRegistration *RegistrationRequestPayload,
+	opts ...Option,
 ) (*Server, error) {
+	if err := userRegistration.validate(); err != nil {
+		return nil, err
+	}
 	user := userRegistration.U
 
+	if !userRegistration.Version.supported() {
+		return nil, ErrUnsupportedVersion
+	}
+
+	if !userRegistration.Codec.Available() {
+		return nil, crypto.ErrUnsupportedCodec
+	}
+
+	if !userRegistration.Hash.Available() {
+		return nil, crypto.ErrUnsupportedHash
+	}
+
+	if _, err := crypto.LookupCurve(curve); err != nil {
+		return nil, err
+	}
+
+	if π, err := userRegistration.PI.Decode(curve); err != nil || π.Sign() == 0 {
+		return nil, malformed("PI")
+	}
+
 	if user == server {
 		return nil, errors.New("user and server name cannot be the same")
 	}
 
+	// -- T is used in every login of this user
+	if userRegistration.Codec == crypto.CodecCompressed {
+		crypto.Precompute(curve, userRegistration.T)
+	}
+	if _, err := decodeVerifier(userRegistration.Codec, curve, userRegistration.T); err != nil {
+		return nil, err
+	}
+
 	return &Server{
 		UserIdentifier:   user,
 		ServerName:       server,
 		Curve:            curve,
 		CurveParams:      curve.Params(),
 		UserRegistration: userRegistration,
+		config:           newConfig(opts),
 	}, nil
 }
 
+func (server *Server) ciphersuite() string {
+	_, registration := server.account()
+	return ciphersuiteName(server.Curve, registration.Hash)
+}
+
+// suite returns the hashing of logins of the record.
+func (server *Server) suite(registration *RegistrationRequestPayload) *crypto.Suite {
+	return registration.Version.suite(server.Curve, registration.Hash, registration.Codec)
+}
+
+// Username returns the username the server is bound to.
+func (server *Server) Username() string {
+	server.mutex.RLock()
+	defer server.mutex.RUnlock()
+	return server.UserIdentifier
+}
+
+// Registration returns the record of the user the server is bound to.
+func (server *Server) Registration() *RegistrationRequestPayload {
+	server.mutex.RLock()
+	defer server.mutex.RUnlock()
+	return server.UserRegistration
+}
+
+// account returns the username and record as one consistent snapshot.
+func (server *Server) account() (string, *RegistrationRequestPayload) {
+	server.mutex.RLock()
+	defer server.mutex.RUnlock()
+	return server.UserIdentifier, server.UserRegistration
+}
+
 func (server *Server) RegisterUser() *RegistrationResponse {
+	// -- Cannot fail, the background context is never cancelled
+	registration, _ := server.RegisterUserContext(context.Background())
+	return registration
+}
+
+// RegisterUserContext is RegisterUser with a context, it fails only if ctx
+// is already done.
+func (server *Server) RegisterUserContext(ctx context.Context) (*RegistrationResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	defer server.emit(ctx, EventRegistration, "", started, nil)
+	defer server.config.observe(OperationRegisterUser, server.ciphersuite(), started, nil)
+
+	suite := server.suite(server.Registration())
 	x3 := crypto.GenerateKey(server.Curve)
-	X3 := crypto.MultiplyG(server.Curve, x3)
-	PI3 := crypto.GenerateZKP(server.Curve, server.CurveParams.N, x3, X3, server.ServerName)
+	X3 := suite.Codec.Encode(crypto.MultiplyBase(server.Curve, x3))
+	if suite.Codec == crypto.CodecCompressed {
+		crypto.Precompute(server.Curve, X3)
+	}
+	PI3 := suite.GenerateZKP(crypto.BasePoint(server.Curve), x3, X3, server.ServerName)
 
 	payload := &RegistrationResponsePayload{
 		X3:  X3,
@@ -46,45 +145,183 @@ func (server *Server) RegisterUser() *RegistrationResponse {
 
 	return &RegistrationResponse{
 		Payload: payload,
+	}, nil
+}
+
+// MigrateServerName re-registers a record after the server has been renamed
+// (server.ServerName is the new name). A fresh X3 / PI3 is generated under
+// the new name, while the old registration keeps answering to legacyName
+// until the grace period ends, so clients can be updated in the meantime.
+func (server *Server) MigrateServerName(
+	serverRegistration *RegistrationResponse,
+	legacyName string,
+	grace time.Duration,
+) (*RegistrationResponse, error) {
+	return server.MigrateServerNameContext(context.Background(), serverRegistration, legacyName, grace)
+}
+
+func (server *Server) MigrateServerNameContext(
+	ctx context.Context,
+	serverRegistration *RegistrationResponse,
+	legacyName string,
+	grace time.Duration,
+) (*RegistrationResponse, error) {
+	if err := serverRegistration.validate(); err != nil {
+		return nil, err
+	}
+
+	if legacyName == server.ServerName {
+		return nil, errors.New("legacy server name is the current server name")
+	}
+
+	if legacyName == server.Username() {
+		return nil, errors.New("user and server name cannot be the same")
+	}
+
+	migrated, err := server.RegisterUserContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	migrated.Legacy = &LegacyServerName{
+		Name:         legacyName,
+		Until:        time.Now().Add(grace),
+		Registration: &RegistrationResponse{Payload: serverRegistration.Payload},
+	}
+
+	return migrated, nil
+}
+
+// resolveServerName picks the server identity for a login, clients that do
+// not announce one get the current ServerName.
+func (server *Server) resolveServerName(
+	serverRegistration *RegistrationResponse,
+	announced string,
+) (string, *RegistrationResponse, error) {
+	if announced == "" || announced == server.ServerName {
+		return server.ServerName, serverRegistration, nil
+	}
+
+	legacy := serverRegistration.Legacy
+	if legacy != nil && legacy.Name == announced && time.Now().Before(legacy.Until) {
+		return legacy.Name, legacy.Registration, nil
 	}
+
+	return "", nil, ErrUnknownServerName
 }
 
 func (server *Server) AuthInit(
 	serverRegistration *RegistrationResponse,
 	clientInit *ClientAuthInitRequestPayload,
 ) (*ServerAuthInitResponse, error) {
-	G := crypto.GetG(server.Curve)
+	return server.AuthInitContext(context.Background(), serverRegistration, clientInit)
+}
+
+// AuthInitContext is AuthInit with a context, which is passed to the
+// throttler and event hook and checked between the expensive steps.
+func (server *Server) AuthInitContext(
+	ctx context.Context,
+	serverRegistration *RegistrationResponse,
+	clientInit *ClientAuthInitRequestPayload,
+) (*ServerAuthInitResponse, error) {
+	started := time.Now()
+	handshakeID := newHandshakeID()
+
+	serverInit, err := server.authInit(ctx, handshakeID, serverRegistration, clientInit)
+	server.config.observe(OperationAuthInit, server.ciphersuite(), started, err)
+	if err != nil {
+		server.emit(ctx, EventLoginFailure, handshakeID, started, err)
+		return nil, err
+	}
+
+	return serverInit, nil
+}
+
+func (server *Server) authInit(
+	ctx context.Context,
+	handshakeID string,
+	serverRegistration *RegistrationResponse,
+	clientInit *ClientAuthInitRequestPayload,
+) (*ServerAuthInitResponse, error) {
+	G := crypto.BasePoint(server.Curve)
 	curve := server.Curve
+	user, registration := server.account()
+
+	if err := clientInit.validate(); err != nil {
+		return nil, err
+	}
 
-	if crypto.VerifyZKP(server.Curve, G, clientInit.X1, *clientInit.PI1, server.UserIdentifier) == false {
-		return nil, errors.New("ZKP Verification Failed for PI1")
+	if clientInit.Version != registration.Version {
+		return nil, ErrVersionMismatch
 	}
 
-	if crypto.VerifyZKP(server.Curve, G, clientInit.X2, *clientInit.PI2, server.UserIdentifier) == false {
-		return nil, errors.New("ZKP Verification Failed for PI2")
+	if clientInit.Codec != registration.Codec {
+		return nil, ErrCodecMismatch
 	}
 
-	if server.UserIdentifier == server.ServerName {
-		return nil, errors.New("user and Server cannot have the same name")
+	if clientInit.Hash != registration.Hash {
+		return nil, ErrHashMismatch
 	}
 
-	x4 := crypto.GenerateKey(server.Curve)
-	X4 := crypto.MultiplyG(curve, x4)
-	PI4 := crypto.GenerateZKP(curve, server.CurveParams.N, x4, X4, server.ServerName)
-	X1X2, err := crypto.AddPoints(curve, clientInit.X1, clientInit.X2)
+	if err := server.allowLogin(ctx); err != nil {
+		return nil, err
+	}
+
+	serverName, serverRegistration, err := server.resolveServerName(serverRegistration, clientInit.S)
 	if err != nil {
 		return nil, err
 	}
-	GBeta, err := crypto.AddPoints(curve, X1X2, serverRegistration.Payload.X3)
+
+	suite := server.suite(registration)
+
+	// -- Usually loaded from storage, a no-op once it is cached
+	if suite.Codec == crypto.CodecCompressed {
+		crypto.Precompute(curve, serverRegistration.Payload.X3)
+	}
+
+	// -- Decoded once, a point that does not decode fails its proof
+	X1, err := suite.Codec.Decode(curve, clientInit.X1)
+	if err != nil {
+		return nil, ErrPI1Verification
+	}
+
+	X2, err := suite.Codec.Decode(curve, clientInit.X2)
+	if err != nil {
+		return nil, ErrPI2Verification
+	}
+
+	err = server.config.verifyZKPs(server.ciphersuite(), suite,
+		zkpCheck{crypto.ZKPStatement{Generator: G, X: X1, ZKP: *clientInit.PI1, Prover: user}, OperationVerifyPI1, ErrPI1Verification},
+		zkpCheck{crypto.ZKPStatement{Generator: G, X: X2, ZKP: *clientInit.PI2, Prover: user}, OperationVerifyPI2, ErrPI2Verification},
+	)
 	if err != nil {
 		return nil, err
 	}
-	x4Pi := crypto.ModuloN(crypto.Multiply(x4, server.UserRegistration.PI), server.CurveParams.N)
-	β, err := crypto.MultiplyPoint(curve, &GBeta, x4Pi)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if user == serverName {
+		return nil, errors.New("user and Server cannot have the same name")
+	}
+
+	X3, err := suite.Codec.Decode(curve, serverRegistration.Payload.X3)
 	if err != nil {
 		return nil, err
 	}
-	PIBeta := crypto.GenerateZKPGProvided(curve, GBeta, server.CurveParams.N, x4Pi, β, server.ServerName)
+
+	x4 := crypto.GenerateKey(server.Curve)
+	X4 := suite.Codec.Encode(crypto.MultiplyBase(curve, x4))
+	PI4 := suite.GenerateZKP(G, x4, X4, serverName)
+	GBeta := X1.Add(X2).Add(X3)
+	π, err := registration.PI.Decode(curve)
+	if err != nil {
+		return nil, malformed("PI")
+	}
+	x4Pi := crypto.ModuloN(crypto.Multiply(x4, π), server.CurveParams.N)
+	β := suite.Codec.Encode(GBeta.Multiply(x4Pi))
+	PIBeta := suite.GenerateZKP(GBeta, x4Pi, β, serverName)
 
 	payload := &ServerAuthInitResponsePayload{
 		X3:     serverRegistration.Payload.X3,
@@ -96,9 +333,11 @@ func (server *Server) AuthInit(
 	}
 
 	return &ServerAuthInitResponse{
-		Payload: payload,
-		Xx4:     x4,
-		GBeta:   GBeta,
+		Payload:     payload,
+		Xx4:         x4,
+		GBeta:       suite.Codec.Encode(GBeta),
+		ServerName:  serverName,
+		HandshakeID: handshakeID,
 	}, nil
 }
 
@@ -107,101 +346,452 @@ func (server *Server) AuthValidate(
 	clientValidate *ClientAuthValidateRequestPayload,
 	serverInit *ServerAuthInitResponse,
 ) (*ServerAuthValidateResponse, error) {
-	curve := server.Curve
-	Gα, err := crypto.AddPoints(curve, clientInit.X1, serverInit.Payload.X3)
+	return server.AuthValidateContext(context.Background(), clientInit, clientValidate, serverInit)
+}
+
+// AuthValidateContext is AuthValidate with a context, which is passed to the
+// throttler and event hook and checked between the expensive steps. A login
+// abandoned because ctx is done is not counted as a failed guess.
+func (server *Server) AuthValidateContext(
+	ctx context.Context,
+	clientInit *ClientAuthInitRequestPayload,
+	clientValidate *ClientAuthValidateRequestPayload,
+	serverInit *ServerAuthInitResponse,
+) (*ServerAuthValidateResponse, error) {
+	started := time.Now()
+
+	// -- Malformed messages are not a password guess, they are not counted
+	err := errors.Join(clientInit.validate(), clientValidate.validate(), serverInit.validate())
 	if err != nil {
+		server.config.observe(OperationAuthValidate, server.ciphersuite(), started, err)
+		server.emit(ctx, EventLoginFailure, serverInit.handshakeID(), started, err)
+		return nil, err
+	}
+
+	// -- Checked again, handshakes may have been started in parallel
+	if err := server.allowLogin(ctx); err != nil {
+		server.config.observe(OperationAuthValidate, server.ciphersuite(), started, err)
+		server.emit(ctx, EventLoginFailure, serverInit.HandshakeID, started, err)
 		return nil, err
 	}
-	Gα, err = crypto.AddPoints(curve, Gα, serverInit.Payload.X4)
+
+	serverValidate, err := server.authValidate(ctx, clientInit, clientValidate, serverInit)
+	server.config.observe(OperationAuthValidate, server.ciphersuite(), started, err)
 	if err != nil {
+		server.emit(ctx, EventLoginFailure, serverInit.HandshakeID, started, err)
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, errors.Join(err, server.recordLogin(ctx, false))
+	}
+
+	if err := server.recordLogin(ctx, true); err != nil {
 		return nil, err
 	}
 
-	if crypto.VerifyZKP(curve, Gα, clientValidate.Alpha, *clientValidate.PIAlpha, server.UserIdentifier) == false {
-		return nil, errors.New("ZKP Verification Failed for PIAlpha")
+	server.emit(ctx, EventLoginSuccess, serverInit.HandshakeID, started, nil)
+	return serverValidate, nil
+}
+
+func (server *Server) allowLogin(ctx context.Context) error {
+	if server.config.throttler == nil {
+		return nil
+	}
+	return server.config.throttler.Allow(ctx, server.Username(), server.config.clientAddressOf(ctx))
+}
+
+func (server *Server) recordLogin(ctx context.Context, success bool) error {
+	throttler := server.config.throttler
+	if throttler == nil {
+		return nil
+	}
+
+	if success {
+		return throttler.Success(ctx, server.Username())
+	}
+	return throttler.Failure(ctx, server.Username(), server.config.clientAddressOf(ctx))
+}
+
+func (server *Server) authValidate(
+	ctx context.Context,
+	clientInit *ClientAuthInitRequestPayload,
+	clientValidate *ClientAuthValidateRequestPayload,
+	serverInit *ServerAuthInitResponse,
+) (*ServerAuthValidateResponse, error) {
+	curve := server.Curve
+	user, registration := server.account()
+	suite := server.suite(registration)
+	serverName := serverInit.ServerName
+	if serverName == "" {
+		serverName = server.ServerName
+	}
+	if clientInit.Version != registration.Version {
+		return nil, ErrVersionMismatch
 	}
 
-	x4π := crypto.Multiply(serverInit.Xx4, server.UserRegistration.PI)
-	X2x4π, err := crypto.MultiplyPoint(curve, &clientInit.X2, crypto.ModuloN(x4π, server.CurveParams.N))
+	if clientInit.Codec != registration.Codec {
+		return nil, ErrCodecMismatch
+	}
+
+	if clientInit.Hash != registration.Hash {
+		return nil, ErrHashMismatch
+	}
+
+	r, err := clientValidate.R.Decode(curve)
+	if err != nil {
+		return nil, malformed("r")
+	}
+
+	π, err := registration.PI.Decode(curve)
+	if err != nil {
+		return nil, malformed("PI")
+	}
+
+	X1, X2, err := decodePair(suite.Codec, curve, clientInit.X1, clientInit.X2)
 	if err != nil {
 		return nil, err
 	}
 
-	rawServerKey, err := crypto.SubtractPoints(server.Curve, clientValidate.Alpha, X2x4π)
+	X3, X4, err := decodePair(suite.Codec, curve, serverInit.Payload.X3, serverInit.Payload.X4)
 	if err != nil {
 		return nil, err
 	}
-	rawServerKey, err = crypto.MultiplyPoint(server.Curve, &rawServerKey, serverInit.Xx4)
+
+	α, err := suite.Codec.Decode(curve, clientValidate.Alpha)
+	if err != nil {
+		return nil, ErrPIAlphaVerification
+	}
+
+	Gα := X1.Add(X3).Add(X4)
+	err = server.config.verifyZKP(server.ciphersuite(), suite, zkpCheck{
+		crypto.ZKPStatement{Generator: Gα, X: α, ZKP: *clientValidate.PIAlpha, Prover: user},
+		OperationVerifyPIAlpha, ErrPIAlphaVerification,
+	})
 	if err != nil {
 		return nil, err
 	}
-	serverSessionKey := crypto.Hash(rawServerKey, SessionKey)
-	serverKCKey := crypto.Hash(rawServerKey, ConfirmationKey)
 
-	hServer := crypto.Hash(
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	x4π := crypto.ModuloN(crypto.Multiply(serverInit.Xx4, π), server.CurveParams.N)
+	rawServerKey := suite.Codec.Encode(α.Subtract(X2.Multiply(x4π)).Multiply(serverInit.Xx4))
+	serverSessionKey := deriveKey(suite, rawServerKey, SessionKey)
+	serverKCKey := deriveKey(suite, rawServerKey, ConfirmationKey)
+
+	hServer, err := transcriptHash(
+		suite,
 		rawServerKey,
-		server.UserIdentifier,
-		clientInit.X1, clientInit.X2,
-		clientInit.PI1, clientInit.PI2,
-		server.ServerName,
-		serverInit.Payload.X3, serverInit.Payload.X4,
-		serverInit.Payload.PI3, serverInit.Payload.PI4,
-		serverInit.Payload.Beta, serverInit.Payload.PIBeta,
+		user, clientInit,
+		serverName, serverInit.Payload,
 		clientValidate.Alpha, clientValidate.PIAlpha,
 	)
+	if err != nil {
+		return nil, err
+	}
 
-	hServer = crypto.ModuloN(hServer, server.CurveParams.N)
-
-	clientKCTag2 := crypto.DeriveHMACTag(
+	clientKCTag2 := suite.DeriveHMACTag(
 		serverKCKey,
 		ClientKCKeyTag,
-		server.UserIdentifier,
-		server.ServerName,
+		user,
+		serverName,
 		clientInit.X1, clientInit.X2,
 		serverInit.Payload.X3, serverInit.Payload.X4,
 	)
 
-	if clientValidate.ClientKCTag.Cmp(clientKCTag2) != 0 {
-		return nil, errors.New("client authentication failed, ClientKCTag mismatch")
+	if !registration.Version.kcTagsEqual(clientKCTag2, clientValidate.ClientKCTag) {
+		return nil, ErrClientKCTagMismatch
 	}
 
-	serverKCTag := crypto.DeriveHMACTag(
+	serverKCTag := suite.DeriveHMACTag(
 		serverKCKey,
 		ServerKCKeyTag,
-		server.ServerName,
-		server.UserIdentifier,
+		serverName,
+		user,
 		serverInit.Payload.X3, serverInit.Payload.X4,
 		clientInit.X1, clientInit.X2,
 	)
 
-	G := crypto.GetG(curve)
-	GxRv, err := crypto.MultiplyPoint(curve, &G, clientValidate.R)
+	T, err := suite.Codec.Decode(curve, registration.T)
+	if err != nil {
+		return nil, err
+	}
+
+	X1x := crypto.MultiplyBase(curve, r).Add(T.Multiply(hServer))
+	if !X1.Equal(X1x) {
+		return nil, ErrX1Mismatch
+	}
+
+	payload := &ServerAuthValidateResponsePayload{
+		ServerKCTag: serverKCTag,
+	}
+
+	serverValidate := &ServerAuthValidateResponse{
+		Payload:      payload,
+		HTranscript:  hServer,
+		HandshakeID:  serverInit.HandshakeID,
+		serverName:   serverName,
+		rawServerKey: rawServerKey,
+		user:         user,
+		registration: registration,
+		kcKey:        serverKCKey,
+		sessionKey:   serverSessionKey,
+		strict:       server.config.strictKeyConfirmation,
+	}
+
+	// -- In strict mode the key is only handed out by ConfirmationSent
+	if !serverValidate.strict {
+		serverValidate.ServerSessionKey = serverSessionKey
+	}
+
+	return serverValidate, nil
+}
+
+// verifyCredentialUpdate checks a new verifier (PI, T) sent for user after
+// the login that produced serverValidate.
+func (server *Server) verifyCredentialUpdate(
+	serverValidate *ServerAuthValidateResponse,
+	messageString string,
+	user string,
+	PI crypto.Scalar,
+	T []byte,
+	receivedTag []byte,
+) error {
+	if serverValidate == nil || serverValidate.rawServerKey == nil {
+		return errors.New("credential update requires a validated login")
+	}
+
+	if serverValidate.credentialsUpdated {
+		return errors.New("credentials were already updated in this login")
+	}
+
+	// -- Called with the mutex held, the record is read directly
+	if serverValidate.user != server.UserIdentifier {
+		return errors.New("credential update is for a login of another user")
+	}
+
+	if serverValidate.registration != server.UserRegistration {
+		return ErrRecordChanged
+	}
+
+	if π, err := PI.Decode(server.Curve); err != nil || π.Sign() == 0 {
+		return errors.New("credential update has an invalid PI")
+	}
+
+	suite := server.suite(server.UserRegistration)
+	if _, err := decodeVerifier(suite.Codec, server.Curve, T); err != nil {
+		return errors.New("credential update has an invalid T")
+	}
+
+	tag := credentialUpdateTag(
+		suite,
+		serverValidate.rawServerKey,
+		messageString,
+		user,
+		serverValidate.serverName,
+		PI,
+		T,
+	)
+
+	if !crypto.HMACTagsEqual(tag, receivedTag) {
+		return errors.New("credential update authentication failed, Tag mismatch")
+	}
+
+	return nil
+}
+
+// ChangePassword applies a PasswordChangeRequestPayload sent after the login
+// that produced serverValidate. The MAC proves the request comes from the
+// party that just authenticated with the current password. On success the
+// record is atomically replaced in the store and returned.
+func (server *Server) ChangePassword(
+	store CredentialStore,
+	serverValidate *ServerAuthValidateResponse,
+	request *PasswordChangeRequestPayload,
+) (*RegistrationRequestPayload, error) {
+	return server.ChangePasswordContext(context.Background(), store, serverValidate, request)
+}
+
+func (server *Server) ChangePasswordContext(
+	ctx context.Context,
+	store CredentialStore,
+	serverValidate *ServerAuthValidateResponse,
+	request *PasswordChangeRequestPayload,
+) (*RegistrationRequestPayload, error) {
+	started := time.Now()
+	replacement, err := server.changePassword(ctx, store, serverValidate, request)
 	if err != nil {
+		server.emit(ctx, EventUpdateFailure, serverValidate.handshakeID(), started, err)
 		return nil, err
 	}
-	hServerModN := crypto.ModuloN(hServer, server.CurveParams.N)
-	TxH, err := crypto.MultiplyPoint(curve, &server.UserRegistration.T, hServerModN)
+
+	server.emit(ctx, EventPasswordChange, serverValidate.handshakeID(), started, nil)
+	return replacement, nil
+}
+
+func (server *Server) changePassword(
+	ctx context.Context,
+	store CredentialStore,
+	serverValidate *ServerAuthValidateResponse,
+	request *PasswordChangeRequestPayload,
+) (*RegistrationRequestPayload, error) {
+	if err := request.validate(); err != nil {
+		return nil, err
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if request.U != server.UserIdentifier {
+		return nil, errors.New("password change is for a different user")
+	}
+
+	err := server.verifyCredentialUpdate(
+		serverValidate,
+		PasswordChangeKeyTag,
+		server.UserIdentifier,
+		request.PI, request.T,
+		request.Tag,
+	)
 	if err != nil {
 		return nil, err
 	}
-	X1x, err := crypto.AddPoints(curve, GxRv, TxH)
+
+	replacement := &RegistrationRequestPayload{
+		U:       server.UserIdentifier,
+		PI:      append(crypto.Scalar(nil), request.PI...),
+		T:       request.T,
+		Version: server.UserRegistration.Version,
+		Codec:   server.UserRegistration.Codec,
+		Hash:    server.UserRegistration.Hash,
+	}
+
+	if err := store.ReplaceRegistration(ctx, server.UserIdentifier, server.UserRegistration, replacement); err != nil {
+		return nil, err
+	}
+
+	serverValidate.credentialsUpdated = true
+	server.UserRegistration = replacement
+	return replacement, nil
+}
+
+// ChangeUsername applies a UsernameChangeRequestPayload sent after the login
+// that produced serverValidate. Since t = H(user, pass) the client has
+// re-registered under the new name, the store moves the record atomically.
+// On success the server is bound to the new username.
+func (server *Server) ChangeUsername(
+	store CredentialStore,
+	serverValidate *ServerAuthValidateResponse,
+	request *UsernameChangeRequestPayload,
+) (*RegistrationRequestPayload, error) {
+	return server.ChangeUsernameContext(context.Background(), store, serverValidate, request)
+}
+
+func (server *Server) ChangeUsernameContext(
+	ctx context.Context,
+	store CredentialStore,
+	serverValidate *ServerAuthValidateResponse,
+	request *UsernameChangeRequestPayload,
+) (*RegistrationRequestPayload, error) {
+	started := time.Now()
+
+	// -- Reported under the name the login was made with
+	user := server.Username()
+	replacement, err := server.changeUsername(ctx, store, serverValidate, request)
 	if err != nil {
+		server.emitFor(ctx, user, EventUpdateFailure, serverValidate.handshakeID(), started, err)
 		return nil, err
 	}
 
-	if !crypto.PointsEqual(curve, clientInit.X1, X1x) {
-		return nil, errors.New("client authentication failed, X1 mismatch")
+	server.emitFor(ctx, user, EventUsernameChange, serverValidate.handshakeID(), started, nil)
+	return replacement, nil
+}
+
+func (server *Server) changeUsername(
+	ctx context.Context,
+	store CredentialStore,
+	serverValidate *ServerAuthValidateResponse,
+	request *UsernameChangeRequestPayload,
+) (*RegistrationRequestPayload, error) {
+	if serverValidate == nil {
+		return nil, errors.New("credential update requires a validated login")
 	}
 
-	payload := &ServerAuthValidateResponsePayload{
-		ServerKCTag: serverKCTag,
+	if err := request.validate(); err != nil {
+		return nil, err
 	}
 
-	return &ServerAuthValidateResponse{
-		Payload:          payload,
-		RawServerKey:     rawServerKey,
-		ServerSessionKey: serverSessionKey,
-		ServerKCKey:      serverKCKey,
-		HTranscript:      hServer,
-	}, nil
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if request.U != server.UserIdentifier {
+		return nil, errors.New("username change is for a different user")
+	}
+
+	// -- The record moved must be the one the login was made against
+	if serverValidate.user != request.U {
+		return nil, errors.New("username change is for a login of another user")
+	}
+
+	if request.NewU == "" || request.NewU == request.U {
+		return nil, errors.New("username change has an invalid new username")
+	}
+
+	if request.NewU == server.ServerName || request.NewU == serverValidate.serverName {
+		return nil, errors.New("user and server name cannot be the same")
+	}
+
+	err := server.verifyCredentialUpdate(
+		serverValidate,
+		UsernameChangeKeyTag,
+		request.NewU,
+		request.PI, request.T,
+		request.Tag,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	replacement := &RegistrationRequestPayload{
+		U:       request.NewU,
+		PI:      append(crypto.Scalar(nil), request.PI...),
+		T:       request.T,
+		Version: server.UserRegistration.Version,
+		Codec:   server.UserRegistration.Codec,
+		Hash:    server.UserRegistration.Hash,
+	}
+
+	if err := store.RenameRegistration(ctx, server.UserIdentifier, server.UserRegistration, replacement); err != nil {
+		return nil, err
+	}
+
+	serverValidate.credentialsUpdated = true
+	server.UserIdentifier = replacement.U
+	server.UserRegistration = replacement
+	return replacement, nil
+}
+
+// decodeVerifier decodes the T of a record, which must be a point of the
+// prime order subgroup other than the identity.
+func decodeVerifier(codec crypto.PointCodec, curve elliptic.Curve, T []byte) (*crypto.Point, error) {
+	point, err := codec.Decode(curve, T)
+	if err != nil || point.IsIdentity() || !point.InPrimeOrderSubgroup() {
+		return nil, crypto.ErrInvalidPoint
+	}
+	return point, nil
+}
+
+func decodePair(codec crypto.PointCodec, curve elliptic.Curve, first []byte, second []byte) (*crypto.Point, *crypto.Point, error) {
+	firstPoint, err := codec.Decode(curve, first)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secondPoint, err := codec.Decode(curve, second)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return firstPoint, secondPoint, nil
 }
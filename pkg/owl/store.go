@@ -0,0 +1,108 @@
+package owl
+
+import (
+	"context"
+	"crypto/subtle"
+	"sync"
+)
+
+// CredentialStore persists the registration records of users, it is used by
+// the flows that update a record after registration (e.g. ChangePassword).
+type CredentialStore interface {
+	// ReplaceRegistration atomically swaps the stored record of user from
+	// current to replacement. It must fail with ErrRecordChanged if the
+	// stored record is no longer current.
+	ReplaceRegistration(ctx context.Context, user string, current *RegistrationRequestPayload, replacement *RegistrationRequestPayload) error
+
+	// RenameRegistration atomically moves the record of user to
+	// replacement.U. It must fail with ErrRecordChanged if the stored record
+	// is no longer current and with ErrUserExists if the new name is taken.
+	RenameRegistration(ctx context.Context, user string, current *RegistrationRequestPayload, replacement *RegistrationRequestPayload) error
+}
+
+// MemoryCredentialStore is an in-memory CredentialStore, safe for concurrent
+// use. It is mostly useful for tests and examples.
+type MemoryCredentialStore struct {
+	mutex   sync.Mutex
+	records map[string]*RegistrationRequestPayload
+}
+
+func NewMemoryCredentialStore() *MemoryCredentialStore {
+	return &MemoryCredentialStore{
+		records: make(map[string]*RegistrationRequestPayload),
+	}
+}
+
+func (store *MemoryCredentialStore) Store(registration *RegistrationRequestPayload) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.records[registration.U] = registration
+}
+
+func (store *MemoryCredentialStore) Load(user string) (*RegistrationRequestPayload, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	registration, ok := store.records[user]
+	if !ok {
+		return nil, ErrUnknownUser
+	}
+	return registration, nil
+}
+
+func (store *MemoryCredentialStore) ReplaceRegistration(
+	_ context.Context,
+	user string,
+	current *RegistrationRequestPayload,
+	replacement *RegistrationRequestPayload,
+) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	stored, ok := store.records[user]
+	if !ok {
+		return ErrUnknownUser
+	}
+
+	if !sameRegistration(stored, current) {
+		return ErrRecordChanged
+	}
+
+	store.records[user] = replacement
+	return nil
+}
+
+func (store *MemoryCredentialStore) RenameRegistration(
+	_ context.Context,
+	user string,
+	current *RegistrationRequestPayload,
+	replacement *RegistrationRequestPayload,
+) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	stored, ok := store.records[user]
+	if !ok {
+		return ErrUnknownUser
+	}
+
+	if !sameRegistration(stored, current) {
+		return ErrRecordChanged
+	}
+
+	if _, taken := store.records[replacement.U]; taken {
+		return ErrUserExists
+	}
+
+	delete(store.records, user)
+	store.records[replacement.U] = replacement
+	return nil
+}
+
+func sameRegistration(a *RegistrationRequestPayload, b *RegistrationRequestPayload) bool {
+	if a == nil || b == nil || len(a.PI) == 0 || len(b.PI) == 0 {
+		return false
+	}
+	return a.U == b.U &&
+		subtle.ConstantTimeCompare(a.PI, b.PI) == 1 &&
+		subtle.ConstantTimeCompare(a.T, b.T) == 1
+}
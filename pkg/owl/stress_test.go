@@ -0,0 +1,197 @@
+package owl
+
+import (
+	"context"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+)
+
+// -- Concurrent handshakes on shared Server and Client instances, run them
+// under the race detector: go test -race ./pkg/owl
+
+// stressConfigs are the configurations the stress tests run with: parallel
+// verification, and combined verification on secp256k1.
+var stressConfigs = []struct {
+	name  string
+	curve elliptic.Curve
+	opts  []Option
+}{
+	{"P-256", elliptic.P256(), []Option{WithParallelVerification(4)}},
+	{"secp256k1", crypto.Secp256k1(), []Option{WithPointCodec(crypto.CodecUncompressed)}},
+}
+
+// stressHandshakes is the number of handshakes per test.
+func stressHandshakes() int {
+	if testing.Short() {
+		return 16
+	}
+	return 200
+}
+
+const stressConcurrency = 16
+
+// tryLogin is login returning its error, for other goroutines than the one
+// of the test.
+func tryLogin(client *Client, server *Server, serverRegistration *RegistrationResponse) (*ClientAuthValidateRequest, *ServerAuthValidateResponse, error) {
+	clientInit := client.AuthInit()
+	serverInit, err := server.AuthInit(serverRegistration, clientInit.Payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clientValidate, err := client.AuthValidate(clientInit, serverInit.Payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serverValidate, err := server.AuthValidate(clientInit.Payload, clientValidate.Payload, serverInit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = client.VerifyResponse(clientInit, clientValidate, serverInit.Payload, serverValidate.Payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if clientValidate.ClientSessionKey.Cmp(serverValidate.ServerSessionKey) != 0 {
+		return nil, nil, errors.New("session keys differ")
+	}
+	return clientValidate, serverValidate, nil
+}
+
+// inParallel runs task n times on concurrency goroutines and returns the
+// first error.
+func inParallel(n int, concurrency int, task func(i int) error) error {
+	var next atomic.Int64
+	var first error
+	var once sync.Once
+	var wait sync.WaitGroup
+
+	for worker := 0; worker < concurrency; worker++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				if err := task(i); err != nil {
+					once.Do(func() { first = fmt.Errorf("handshake %d: %w", i, err) })
+				}
+			}
+		}()
+	}
+
+	wait.Wait()
+	return first
+}
+
+func TestConcurrentLogins(t *testing.T) {
+	for _, config := range stressConfigs {
+		t.Run(config.name, func(t *testing.T) {
+			var events atomic.Int64
+			opts := append([]Option{
+				WithThrottler(NewThrottler(NewMemoryCounterStore())),
+				WithEventHook(EventHookFunc(func(context.Context, Event) { events.Add(1) })),
+				WithMetrics(newRecordingMetrics()),
+			}, config.opts...)
+			client, server, serverRegistration := register(t, config.curve, "alice", "password", opts...)
+
+			n := stressHandshakes()
+			before := events.Load()
+			err := inParallel(n, stressConcurrency, func(int) error {
+				_, _, err := tryLogin(client, server, serverRegistration)
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// -- One success event per login
+			if logged := events.Load() - before; logged != int64(n) {
+				t.Errorf("%d events for %d logins", logged, n)
+			}
+		})
+	}
+}
+
+// TestConcurrentWrongPassword interleaves logins with the right and a wrong
+// password on the same server, without a throttler so that the good logins
+// are not refused.
+func TestConcurrentWrongPassword(t *testing.T) {
+	for _, config := range stressConfigs {
+		t.Run(config.name, func(t *testing.T) {
+			client, server, serverRegistration := register(t, config.curve, "bob", "password", config.opts...)
+			wrong, err := ClientInit("bob", "wrong password", "server", config.curve, config.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = inParallel(stressHandshakes(), stressConcurrency, func(i int) error {
+				if i%2 == 0 {
+					_, _, err := tryLogin(client, server, serverRegistration)
+					return err
+				}
+
+				_, _, err := tryLogin(wrong, server, serverRegistration)
+				if !errors.Is(err, ErrClientKCTagMismatch) {
+					return fmt.Errorf("wrong password: got %v", err)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// TestConcurrentPasswordChange sends the same password change from many
+// goroutines, exactly one of them may apply it.
+func TestConcurrentPasswordChange(t *testing.T) {
+	for _, config := range stressConfigs {
+		t.Run(config.name, func(t *testing.T) {
+			client, server, serverRegistration := register(t, config.curve, "carol", "password", config.opts...)
+			store := NewMemoryCredentialStore()
+			store.Store(server.Registration())
+
+			clientValidate, serverValidate := login(t, client, server, serverRegistration)
+			change, err := client.ChangePassword(clientValidate, []byte("new password"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var applied atomic.Int64
+			_ = inParallel(stressConcurrency, stressConcurrency, func(int) error {
+				if _, err := server.ChangePassword(store, serverValidate, change.Payload); err == nil {
+					applied.Add(1)
+				}
+				return nil
+			})
+			if applied.Load() != 1 {
+				t.Fatalf("password change applied %d times", applied.Load())
+			}
+
+			stored, err := store.Load("carol")
+			if err != nil || stored != server.Registration() {
+				t.Fatal("store and server disagree on the record")
+			}
+
+			err = inParallel(stressConcurrency, stressConcurrency, func(int) error {
+				_, _, err := tryLogin(change.Client, server, serverRegistration)
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package owl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrThrottled = errors.New("too many failed logins")
+
+// ThrottledError is returned when a login is refused by the Throttler.
+type ThrottledError struct {
+	RetryAfter time.Duration
+	LockedOut  bool
+}
+
+func (err *ThrottledError) Error() string {
+	if err.LockedOut {
+		return fmt.Sprintf("account locked after too many failed logins, retry in %s", err.RetryAfter)
+	}
+	return fmt.Sprintf("too many failed logins, retry in %s", err.RetryAfter)
+}
+
+func (err *ThrottledError) Unwrap() error {
+	return ErrThrottled
+}
+
+// CounterStore keeps the failed login counters of the Throttler. It must be
+// safe for concurrent use when the Throttler is shared between servers.
+type CounterStore interface {
+	// Get returns the number of failures recorded for key and the time of
+	// the last one, an unknown key has no failures.
+	Get(ctx context.Context, key string) (failures int, last time.Time, err error)
+
+	// Increment records a failure for key at now and returns the new count.
+	// If the last failure is forgetAfter or more before now the earlier
+	// failures are forgotten and the count starts over at 1, in the same
+	// atomic step so concurrent failures are all counted. A forgetAfter of 0
+	// never forgets.
+	Increment(ctx context.Context, key string, now time.Time, forgetAfter time.Duration) (int, error)
+
+	// Reset forgets the failures recorded for key.
+	Reset(ctx context.Context, key string) error
+}
+
+// MemoryCounterStore is an in-memory CounterStore, safe for concurrent use.
+type MemoryCounterStore struct {
+	mutex    sync.Mutex
+	counters map[string]failureCounter
+}
+
+type failureCounter struct {
+	failures int
+	last     time.Time
+}
+
+func NewMemoryCounterStore() *MemoryCounterStore {
+	return &MemoryCounterStore{
+		counters: make(map[string]failureCounter),
+	}
+}
+
+func (store *MemoryCounterStore) Get(_ context.Context, key string) (int, time.Time, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	counter := store.counters[key]
+	return counter.failures, counter.last, nil
+}
+
+func (store *MemoryCounterStore) Increment(_ context.Context, key string, now time.Time, forgetAfter time.Duration) (int, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	counter := store.counters[key]
+	if forgetAfter > 0 && now.Sub(counter.last) >= forgetAfter {
+		counter.failures = 0
+	}
+	counter.failures++
+	counter.last = now
+	store.counters[key] = counter
+	return counter.failures, nil
+}
+
+func (store *MemoryCounterStore) Reset(_ context.Context, key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.counters, key)
+	return nil
+}
+
+// Throttler limits online password guessing. Failures are counted per
+// username and per client address; after each failure the key has to wait
+// BaseDelay * 2^(failures-1) (capped at MaxDelay) and after LockoutAfter
+// failures it is locked for LockoutFor. Failures older than ForgetAfter are
+// ignored. A Throttler is safe for concurrent use if its store is.
+type Throttler struct {
+	Store        CounterStore
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	LockoutAfter int
+	LockoutFor   time.Duration
+	ForgetAfter  time.Duration
+	Now          func() time.Time
+}
+
+// NewThrottler returns a Throttler with conservative defaults: 1s base
+// delay, 5m max delay, lockout for 1h after 10 failures, and counters
+// forgotten after a day without failures.
+func NewThrottler(store CounterStore) *Throttler {
+	return &Throttler{
+		Store:        store,
+		BaseDelay:    time.Second,
+		MaxDelay:     5 * time.Minute,
+		LockoutAfter: 10,
+		LockoutFor:   time.Hour,
+		ForgetAfter:  24 * time.Hour,
+		Now:          time.Now,
+	}
+}
+
+func userKey(user string) string {
+	return "user:" + user
+}
+
+func addressKey(address string) string {
+	return "addr:" + address
+}
+
+func (throttler *Throttler) keys(user string, address string) []string {
+	keys := []string{userKey(user)}
+	if address != "" {
+		keys = append(keys, addressKey(address))
+	}
+	return keys
+}
+
+// Allow returns a *ThrottledError if a login for user from address must be
+// refused right now, address may be empty.
+func (throttler *Throttler) Allow(ctx context.Context, user string, address string) error {
+	now := throttler.Now()
+	var refused *ThrottledError
+
+	for _, key := range throttler.keys(user, address) {
+		failures, last, err := throttler.Store.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		if failures == 0 || (throttler.ForgetAfter > 0 && now.Sub(last) >= throttler.ForgetAfter) {
+			continue
+		}
+
+		lockedOut := throttler.LockoutAfter > 0 && failures >= throttler.LockoutAfter
+		wait := throttler.delay(failures)
+		if lockedOut {
+			wait = throttler.LockoutFor
+		}
+
+		retryAfter := last.Add(wait).Sub(now)
+		if retryAfter <= 0 {
+			continue
+		}
+
+		if refused == nil || retryAfter > refused.RetryAfter {
+			refused = &ThrottledError{RetryAfter: retryAfter, LockedOut: lockedOut}
+		}
+	}
+
+	if refused != nil {
+		return refused
+	}
+	return nil
+}
+
+func (throttler *Throttler) delay(failures int) time.Duration {
+	delay := throttler.BaseDelay
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if throttler.MaxDelay > 0 && delay >= throttler.MaxDelay {
+			return throttler.MaxDelay
+		}
+	}
+	return delay
+}
+
+// Failure records a failed login for user from address.
+func (throttler *Throttler) Failure(ctx context.Context, user string, address string) error {
+	now := throttler.Now()
+	for _, key := range throttler.keys(user, address) {
+		// -- The store starts over once the previous failures are forgotten
+		if _, err := throttler.Store.Increment(ctx, key, now, throttler.ForgetAfter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Success records a successful login for user. Only the username counter is
+// reset, an address shared by many users keeps its failures.
+func (throttler *Throttler) Success(ctx context.Context, user string) error {
+	return throttler.Store.Reset(ctx, userKey(user))
+}
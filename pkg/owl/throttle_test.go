@@ -0,0 +1,210 @@
+package owl
+
+import (
+	"context"
+	"crypto/elliptic"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failLogin runs a login of client against server that fails in
+// AuthValidate, with ctx passed to the server.
+func failLogin(t *testing.T, ctx context.Context, client *Client, server *Server, serverRegistration *RegistrationResponse) {
+	t.Helper()
+	clientInit := client.AuthInit()
+	serverInit, err := server.AuthInitContext(ctx, serverRegistration, clientInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientValidate, err := client.AuthValidate(clientInit, serverInit.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.AuthValidateContext(ctx, clientInit.Payload, clientValidate.Payload, serverInit); err == nil {
+		t.Fatal("login with a wrong password succeeded")
+	}
+}
+
+func TestThrottlerCountsTheAddressOfEachCall(t *testing.T) {
+	curve := elliptic.P256()
+	store := NewMemoryCounterStore()
+	_, server, serverRegistration := register(t, curve, "alice", "password", WithThrottler(NewThrottler(store)))
+	guesser, _ := ClientInit("alice", "guess", "server", curve)
+
+	// -- One shared Server, the failure is charged to the address of its call
+	failLogin(t, ContextWithClientAddress(context.Background(), "192.0.2.1"), guesser, server, serverRegistration)
+
+	for address, want := range map[string]int{"192.0.2.1": 1, "192.0.2.2": 0} {
+		failures, _, _ := store.Get(context.Background(), addressKey(address))
+		if failures != want {
+			t.Errorf("%s has %d failures, want %d", address, failures, want)
+		}
+	}
+}
+
+// testThrottler is a Throttler on a clock the test moves.
+func testThrottler() (*Throttler, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	throttler := &Throttler{
+		Store:        NewMemoryCounterStore(),
+		BaseDelay:    time.Second,
+		MaxDelay:     8 * time.Second,
+		LockoutAfter: 6,
+		LockoutFor:   time.Hour,
+		ForgetAfter:  24 * time.Hour,
+		Now:          func() time.Time { return now },
+	}
+	return throttler, &now
+}
+
+// retryAfter is the wait Allow imposes on alice, 0 if she is allowed.
+func retryAfter(t *testing.T, throttler *Throttler) (time.Duration, bool) {
+	t.Helper()
+	err := throttler.Allow(context.Background(), "alice", "")
+	if err == nil {
+		return 0, false
+	}
+	var throttled *ThrottledError
+	if !errors.As(err, &throttled) || !errors.Is(err, ErrThrottled) {
+		t.Fatalf("Allow: %v", err)
+	}
+	return throttled.RetryAfter, throttled.LockedOut
+}
+
+func TestThrottlerBackoff(t *testing.T) {
+	throttler, now := testThrottler()
+	ctx := context.Background()
+
+	// -- The wait doubles after each failure up to MaxDelay, the sixth
+	// failure locks the account
+	for failures, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second} {
+		if err := throttler.Failure(ctx, "alice", ""); err != nil {
+			t.Fatal(err)
+		}
+		wait, lockedOut := retryAfter(t, throttler)
+		if wait != want || lockedOut {
+			t.Fatalf("after %d failures: wait %s (locked out %v), want %s", failures+1, wait, lockedOut, want)
+		}
+
+		*now = now.Add(wait)
+		if wait, _ := retryAfter(t, throttler); wait != 0 {
+			t.Fatalf("after %d failures: still throttled once the wait is over", failures+1)
+		}
+	}
+
+	if err := throttler.Failure(ctx, "alice", ""); err != nil {
+		t.Fatal(err)
+	}
+	if wait, lockedOut := retryAfter(t, throttler); wait != time.Hour || !lockedOut {
+		t.Fatalf("after 6 failures: wait %s (locked out %v), want a lockout of 1h", wait, lockedOut)
+	}
+	*now = now.Add(time.Hour - time.Second)
+	if wait, lockedOut := retryAfter(t, throttler); wait != time.Second || !lockedOut {
+		t.Fatalf("lockout ended early: wait %s (locked out %v)", wait, lockedOut)
+	}
+	*now = now.Add(time.Second)
+	if wait, _ := retryAfter(t, throttler); wait != 0 {
+		t.Fatal("still locked out after LockoutFor")
+	}
+
+	// -- A success resets the username counter
+	if err := throttler.Success(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if failures, _, _ := throttler.Store.Get(ctx, userKey("alice")); failures != 0 {
+		t.Fatalf("%d failures after a success", failures)
+	}
+}
+
+func TestThrottlerForgetsOldFailures(t *testing.T) {
+	throttler, now := testThrottler()
+	ctx := context.Background()
+
+	for i := 0; i < 6; i++ {
+		if err := throttler.Failure(ctx, "alice", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, lockedOut := retryAfter(t, throttler); !lockedOut {
+		t.Fatal("not locked out after 6 failures")
+	}
+
+	// -- A day later the failures are ignored, the next one starts over
+	*now = now.Add(24 * time.Hour)
+	if wait, _ := retryAfter(t, throttler); wait != 0 {
+		t.Fatalf("throttled for %s by forgotten failures", wait)
+	}
+	if err := throttler.Failure(ctx, "alice", ""); err != nil {
+		t.Fatal(err)
+	}
+	if failures, _, _ := throttler.Store.Get(ctx, userKey("alice")); failures != 1 {
+		t.Fatalf("%d failures after forgetting, want 1", failures)
+	}
+	if wait, lockedOut := retryAfter(t, throttler); wait != time.Second || lockedOut {
+		t.Fatalf("wait %s (locked out %v) after forgetting, want 1s", wait, lockedOut)
+	}
+}
+
+// meetingStore holds each of the first n calls to its store, once made,
+// until all of them have been made, so they overlap even on one CPU.
+type meetingStore struct {
+	CounterStore
+	cond  *sync.Cond
+	calls int
+	n     int
+}
+
+func (store *meetingStore) meet() {
+	store.cond.L.Lock()
+	defer store.cond.L.Unlock()
+	store.calls++
+	for store.calls < store.n {
+		store.cond.Wait()
+	}
+	store.cond.Broadcast()
+}
+
+func (store *meetingStore) Get(ctx context.Context, key string) (int, time.Time, error) {
+	defer store.meet()
+	return store.CounterStore.Get(ctx, key)
+}
+
+func (store *meetingStore) Increment(ctx context.Context, key string, now time.Time, forgetAfter time.Duration) (int, error) {
+	defer store.meet()
+	return store.CounterStore.Increment(ctx, key, now, forgetAfter)
+}
+
+// TestThrottlerCountsConcurrentFailures checks that failures recorded at
+// the same time, once the old ones are to be forgotten, are all counted.
+func TestThrottlerCountsConcurrentFailures(t *testing.T) {
+	throttler, now := testThrottler()
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		if err := throttler.Failure(ctx, "alice", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	*now = now.Add(24 * time.Hour)
+
+	const concurrent = 64
+	store := throttler.Store
+	throttler.Store = &meetingStore{CounterStore: store, cond: sync.NewCond(&sync.Mutex{}), n: concurrent}
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrent; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := throttler.Failure(ctx, "alice", ""); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if failures, _, _ := store.Get(ctx, userKey("alice")); failures != concurrent {
+		t.Fatalf("%d failures recorded, want %d", failures, concurrent)
+	}
+}
@@ -0,0 +1,121 @@
+package owl
+
+import (
+	"errors"
+	"fmt"
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+)
+
+// ErrMalformedMessage is returned, before any cryptography is done, for a
+// message that is missing fields or has fields that can never be valid.
+var ErrMalformedMessage = errors.New("malformed message")
+
+func malformed(field string) error {
+	return fmt.Errorf("%w: missing or invalid %s", ErrMalformedMessage, field)
+}
+
+func validZKP(zkp *crypto.SchnorrZKP) bool {
+	return zkp != nil && len(zkp.V) > 0 && zkp.R != nil && zkp.R.Sign() >= 0
+}
+
+func (payload *RegistrationRequestPayload) validate() error {
+	switch {
+	case payload == nil:
+		return malformed("registration")
+	case len(payload.PI) == 0:
+		return malformed("PI")
+	case len(payload.T) == 0:
+		return malformed("T")
+	}
+	return nil
+}
+
+func (registration *RegistrationResponse) validate() error {
+	switch {
+	case registration == nil || registration.Payload == nil:
+		return malformed("server registration")
+	case len(registration.Payload.X3) == 0:
+		return malformed("X3")
+	case !validZKP(registration.Payload.PI3):
+		return malformed("PI3")
+	}
+	return nil
+}
+
+func (payload *ClientAuthInitRequestPayload) validate() error {
+	switch {
+	case payload == nil:
+		return malformed("client init")
+	case !validZKP(payload.PI1):
+		return malformed("PI1")
+	case !validZKP(payload.PI2):
+		return malformed("PI2")
+	}
+	return nil
+}
+
+func (payload *ClientAuthValidateRequestPayload) validate() error {
+	switch {
+	case payload == nil:
+		return malformed("client validate")
+	case !validZKP(payload.PIAlpha):
+		return malformed("PIAlpha")
+	case len(payload.R) == 0:
+		return malformed("r")
+	}
+	return nil
+}
+
+func (payload *ServerAuthInitResponsePayload) validate() error {
+	switch {
+	case payload == nil:
+		return malformed("server init")
+	case !validZKP(payload.PI3):
+		return malformed("PI3")
+	case !validZKP(payload.PI4):
+		return malformed("PI4")
+	case !validZKP(payload.PIBeta):
+		return malformed("PIBeta")
+	}
+	return nil
+}
+
+func (response *ServerAuthInitResponse) validate() error {
+	switch {
+	case response == nil || response.Xx4 == nil:
+		return malformed("server init state")
+	default:
+		return response.Payload.validate()
+	}
+}
+
+func (payload *ServerAuthValidateResponsePayload) validate() error {
+	if payload == nil {
+		return malformed("server validate")
+	}
+	return nil
+}
+
+func (payload *PasswordChangeRequestPayload) validate() error {
+	switch {
+	case payload == nil:
+		return malformed("password change")
+	case len(payload.PI) == 0:
+		return malformed("PI")
+	case len(payload.T) == 0:
+		return malformed("T")
+	}
+	return nil
+}
+
+func (payload *UsernameChangeRequestPayload) validate() error {
+	switch {
+	case payload == nil:
+		return malformed("username change")
+	case len(payload.PI) == 0:
+		return malformed("PI")
+	case len(payload.T) == 0:
+		return malformed("T")
+	}
+	return nil
+}
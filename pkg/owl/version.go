@@ -0,0 +1,109 @@
+package owl
+
+import (
+	"crypto/elliptic"
+	"errors"
+
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported protocol version")
+	ErrVersionMismatch    = errors.New("client and registration use different protocol versions")
+	ErrCodecMismatch      = errors.New("client and registration use different point encodings")
+	ErrHashMismatch       = errors.New("client and registration use different hash functions")
+)
+
+// Version is the protocol version a registration was made with, every login
+// of the record uses it. Both sides must agree on it: the client announces
+// its version (WithVersion) in the registration and in AuthInit, the server
+// takes it from the stored record.
+type Version uint8
+
+const (
+	// VersionLegacy is the original protocol, the one the Java and TS
+	// implementations speak. It is the default.
+	VersionLegacy Version = 0
+
+	// Version1 hashes the Schnorr challenges and the handshake transcript
+	// with the canonical transcript encoding (crypto.TranscriptV1).
+	Version1 Version = 1
+
+	// Version2 domain separates every hash (t, π, the proof challenges, the
+	// transcript and the derived keys) with ProtocolName, the version, the
+	// ciphersuite and the purpose of the hash.
+	Version2 Version = 2
+
+	// Version3 maps t, π, the proof challenges and the transcript hash to
+	// scalars with RFC 9380 hash_to_field (expand_message_xmd) instead of
+	// reducing a digest modulo N.
+	Version3 Version = 3
+
+	// Version4 length-prefixes every field of the KC tags
+	// (crypto.DeriveFramedHMACTag), the earlier versions write them back to
+	// back.
+	Version4 Version = 4
+
+	LatestVersion = Version4
+)
+
+// ProtocolName is the protocol of the domain of Version2 and later.
+const ProtocolName = "OWL"
+
+func (version Version) supported() bool {
+	return version <= LatestVersion
+}
+
+// suite returns the hashing a login of this version does on curve, with
+// points encoded by codec.
+func (version Version) suite(curve elliptic.Curve, hash crypto.HashFunction, codec crypto.PointCodec) *crypto.Suite {
+	suite := crypto.LegacySuite(curve)
+	suite.Hash = hash
+	suite.Codec = codec
+	if version >= Version1 {
+		suite.Transcript = crypto.TranscriptV1
+	}
+	if version >= Version2 {
+		suite.Domain = &crypto.Domain{
+			Protocol:    ProtocolName,
+			Version:     uint8(version),
+			Ciphersuite: domainCiphersuite(curve, hash, codec),
+		}
+	}
+	if version >= Version3 {
+		suite.HashToField = true
+	}
+	if version >= Version4 {
+		suite.FramedTags = true
+	}
+	return suite
+}
+
+// kcTagsEqual compares a received KC tag with the expected one. The legacy
+// peers send the tag as a big int, VersionLegacy pads one that lost its
+// leading zero bytes, the later versions take it as sent.
+func (version Version) kcTagsEqual(expected []byte, received []byte) bool {
+	if version == VersionLegacy {
+		return crypto.HMACTagsEqualPadded(expected, received)
+	}
+	return crypto.HMACTagsEqual(expected, received)
+}
+
+// ciphersuiteName names a curve and hash function. Suites using SHA-256 keep
+// the bare curve name they had before the hash was selectable.
+func ciphersuiteName(curve elliptic.Curve, hash crypto.HashFunction) string {
+	if hash == crypto.SHA256 {
+		return curve.Params().Name
+	}
+	return curve.Params().Name + "_" + hash.String()
+}
+
+// domainCiphersuite is the ciphersuite written in transcripts, the name of
+// ciphersuiteName followed by the point encoding unless it is compressed.
+func domainCiphersuite(curve elliptic.Curve, hash crypto.HashFunction, codec crypto.PointCodec) string {
+	name := ciphersuiteName(curve, hash)
+	if codec != crypto.CodecCompressed {
+		name += "_" + codec.String()
+	}
+	return name
+}
@@ -0,0 +1,40 @@
+package owl
+
+import (
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/GrzegorzManiak/GOWL/pkg/crypto"
+)
+
+// TestVersionsSeparateHashes derives t, π and every key from the same
+// password and raw key under each version and checks no two agree.
+func TestVersionsSeparateHashes(t *testing.T) {
+	curve := elliptic.P256()
+	rawKey := crypto.MultiplyG(curve, big.NewInt(7))
+
+	seen := make(map[string]string)
+	record := func(name string, value *big.Int) {
+		t.Helper()
+		if other, ok := seen[value.String()]; ok {
+			t.Errorf("%s and %s are the same", name, other)
+		}
+		seen[value.String()] = name
+	}
+
+	for version := VersionLegacy; version <= LatestVersion; version++ {
+		for _, hash := range []crypto.HashFunction{crypto.SHA256, crypto.SHA512} {
+			suite := version.suite(curve, hash, crypto.CodecCompressed)
+			name := fmt.Sprintf("version %d %s", version, hash)
+
+			tValue, π := passwordVerifier(suite, "alice", []byte("password"))
+			record(name+" t", tValue)
+			record(name+" π", π)
+			for _, purpose := range []string{SessionKey, ConfirmationKey, PasswordChangeKey} {
+				record(name+" "+purpose, deriveKey(suite, rawKey, purpose))
+			}
+		}
+	}
+}